@@ -0,0 +1,100 @@
+package impl
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+type CmdCat struct {
+	cmd
+	Verify bool `long:"verify" description:"Checks the CRC32 of the contents, failing if they don't match the index"`
+	At     int  `long:"at" description:"Reads the archive as it was after writing the given block, numbered from 1, instead of the last one"`
+	Input  struct {
+		Paths []string `positional-arg-name:"path" required:"1" description:"names of the items to write."`
+	} `positional-args:"yes"`
+}
+
+func (c *CmdCat) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	ra, size, err := c.buildReaderAt()
+	if err != nil {
+		return err
+	}
+
+	defer c.close()
+	end, err := blockEnd(ra, size, c.At)
+	if err != nil {
+		return err
+	}
+
+	return c.cat(siva.NewReaderAt(ra, end))
+}
+
+func (c *CmdCat) validate() error {
+	if err := c.cmd.validate(); err != nil {
+		return err
+	}
+
+	if len(c.Input.Paths) == 0 {
+		return fmt.Errorf("Invalid path count, please add one or more paths")
+	}
+
+	if c.At < 0 {
+		return fmt.Errorf("Invalid block %d, blocks are numbered from 1", c.At)
+	}
+
+	return nil
+}
+
+func (c *CmdCat) cat(r siva.Reader) error {
+	i, err := r.Index()
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	i = i.Filter()
+	entries := make([]*siva.IndexEntry, len(c.Input.Paths))
+	for n, path := range c.Input.Paths {
+		entries[n] = i.Find(path)
+		if entries[n] == nil {
+			return fmt.Errorf("No entry named %q in the archive", path)
+		}
+	}
+
+	for _, e := range entries {
+		if err := c.writeEntry(r, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CmdCat) writeEntry(r siva.Reader, e *siva.IndexEntry) error {
+	content, err := r.Get(e)
+	if err != nil {
+		return fmt.Errorf("error reading %q: %s", e.Name, err)
+	}
+
+	crc := crc32.NewIEEE()
+	var w io.Writer = defaultOutput
+	if c.Verify {
+		w = io.MultiWriter(defaultOutput, crc)
+	}
+
+	if _, err := io.Copy(w, content); err != nil {
+		return fmt.Errorf("error reading %q: %s", e.Name, err)
+	}
+
+	if c.Verify && crc.Sum32() != e.CRC32 {
+		return fmt.Errorf("error reading %q: %s", e.Name, siva.ErrInvalidCheckshum)
+	}
+
+	return nil
+}
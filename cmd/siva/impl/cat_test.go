@@ -0,0 +1,85 @@
+package impl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type CatSuite struct{}
+
+var _ = Suite(&CatSuite{})
+
+func (s *CatSuite) TestCat(c *C) {
+	cmd := &CmdCat{Verify: true}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Input.Paths = []string{"todo.txt", "readme.txt"}
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	c.Assert(output, Equals, "Get animal handling license."+
+		"This archive contains some text files.")
+}
+
+func (s *CatSuite) TestAt(c *C) {
+	cmd := &CmdCat{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "overwritten.siva")
+	cmd.Input.Paths = []string{"gopher.txt"}
+
+	for at, expected := range map[int]string{
+		0: "Gopher names:\nGeorge\nGeoffrey\nGonzo",
+		1: "GARBAGE\n",
+		2: "Gopher names:\nGeorge\nGeoffrey\nGonzo",
+	} {
+		cmd.At = at
+		output := captureOutput(func() {
+			c.Assert(cmd.Execute(nil), IsNil)
+		})
+
+		c.Assert(output, Equals, expected, Commentf("at %d", at))
+	}
+
+	cmd.At = 3
+	c.Assert(cmd.Execute(nil), ErrorMatches, "Invalid block 3, the archive has 2 blocks")
+}
+
+func (s *CatSuite) TestNotFound(c *C) {
+	cmd := &CmdCat{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Input.Paths = []string{"todo.txt", "missing.txt"}
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), ErrorMatches, `No entry named "missing.txt" in the archive`)
+	})
+
+	c.Assert(output, Equals, "")
+}
+
+func (s *CatSuite) TestVerify(c *C) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "fixtures", "basic.siva"))
+	c.Assert(err, IsNil)
+
+	// corrupt the first byte of gopher.txt, the first entry
+	data[0] = 'g'
+	path := filepath.Join(c.MkDir(), "corrupted.siva")
+	c.Assert(ioutil.WriteFile(path, data, 0666), IsNil)
+
+	cmd := &CmdCat{}
+	cmd.Args.File = path
+	cmd.Input.Paths = []string{"gopher.txt"}
+
+	captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	cmd.Verify = true
+	captureOutput(func() {
+		c.Assert(cmd.Execute(nil), ErrorMatches,
+			`error reading "gopher.txt": `+siva.ErrInvalidCheckshum.Error())
+	})
+}
@@ -0,0 +1,220 @@
+package impl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+var defaultInput io.Reader = os.Stdin
+
+type CmdConvert struct {
+	cmd
+	From    string `long:"from" choice:"tar" choice:"zip" description:"Format of the archive to convert to siva, tar archives can be gzipped"`
+	To      string `long:"to" choice:"tar" choice:"zip" description:"Format of the archive to convert the siva file to"`
+	Append  bool   `long:"append" description:"If append, the files are added to an existing siva file"`
+	Archive struct {
+		Path string `positional-arg-name:"archive" description:"archive read with --from or written with --to, if empty or - the standard input or output is used"`
+	} `positional-args:"yes"`
+}
+
+func (c *CmdConvert) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	if c.To != "" {
+		return c.convertTo()
+	}
+
+	return c.convertFrom()
+}
+
+func (c *CmdConvert) validate() error {
+	if err := c.cmd.validate(); err != nil {
+		return err
+	}
+
+	if (c.From == "") == (c.To == "") {
+		return fmt.Errorf("Please provide either the input format with --from or the output format with --to")
+	}
+
+	if c.To != "" && c.Append {
+		return fmt.Errorf("--append can only be used with --from")
+	}
+
+	return nil
+}
+
+func (c *CmdConvert) isStd() bool {
+	return c.Archive.Path == "" || c.Archive.Path == "-"
+}
+
+func (c *CmdConvert) convertFrom() error {
+	in, err := c.openInput()
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		defer in.Close()
+	}
+
+	if err := c.buildWriter(c.Append); err != nil {
+		return err
+	}
+
+	if err := c.readArchive(in); err != nil {
+		_ = c.abort()
+		if !c.Append {
+			_ = os.Remove(c.Args.File)
+		}
+
+		return err
+	}
+
+	return c.close()
+}
+
+func (c *CmdConvert) openInput() (*os.File, error) {
+	if c.isStd() {
+		return nil, nil
+	}
+
+	f, err := os.Open(c.Archive.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid input file %q, %s", c.Archive.Path, err)
+	}
+
+	return f, nil
+}
+
+func (c *CmdConvert) readArchive(f *os.File) error {
+	var in io.Reader = defaultInput
+	if f != nil {
+		in = f
+	}
+
+	switch c.From {
+	case "zip":
+		return c.readZip(f)
+	default:
+		return c.readTar(in)
+	}
+}
+
+func (c *CmdConvert) readTar(in io.Reader) error {
+	r, err := decompress(in)
+	if err != nil {
+		return err
+	}
+
+	if err := siva.FromTar(c.w, tar.NewReader(r)); err != nil {
+		return fmt.Errorf("error converting tar archive: %s", err)
+	}
+
+	return nil
+}
+
+func (c *CmdConvert) readZip(f *os.File) error {
+	var ra io.ReaderAt
+	var size int64
+	if f != nil {
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		ra, size = f, fi.Size()
+	} else {
+		// zip archives need random access, so the standard input is buffered
+		data, err := ioutil.ReadAll(defaultInput)
+		if err != nil {
+			return err
+		}
+
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return fmt.Errorf("error reading zip archive: %s", err)
+	}
+
+	if err := siva.FromZip(c.w, zr); err != nil {
+		return fmt.Errorf("error converting zip archive: %s", err)
+	}
+
+	return nil
+}
+
+func (c *CmdConvert) convertTo() error {
+	if err := c.buildReader(); err != nil {
+		return err
+	}
+
+	defer c.close()
+
+	if c.isStd() {
+		return c.writeArchive(defaultOutput)
+	}
+
+	f, err := os.Create(c.Archive.Path)
+	if err != nil {
+		return fmt.Errorf("error creating file: %s", err)
+	}
+
+	err = c.writeArchive(f)
+	if errC := f.Close(); errC != nil && err == nil {
+		err = errC
+	}
+
+	if err != nil {
+		_ = os.Remove(c.Archive.Path)
+	}
+
+	return err
+}
+
+func (c *CmdConvert) writeArchive(out io.Writer) error {
+	switch c.To {
+	case "zip":
+		zw := zip.NewWriter(out)
+		if err := siva.ToZip(zw, c.r); err != nil {
+			return fmt.Errorf("error writing zip archive: %s", err)
+		}
+
+		return zw.Close()
+	default:
+		tw := tar.NewWriter(out)
+		if err := siva.ToTar(tw, c.r); err != nil {
+			return fmt.Errorf("error writing tar archive: %s", err)
+		}
+
+		return tw.Close()
+	}
+}
+
+// decompress returns a reader with the decompressed content of r if it's
+// gzipped, or the content as is otherwise.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+
+	return gzip.NewReader(br)
+}
@@ -0,0 +1,209 @@
+package impl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type ConvertSuite struct {
+	folder string
+}
+
+var _ = Suite(&ConvertSuite{})
+
+func (s *ConvertSuite) SetUpTest(c *C) {
+	s.folder = c.MkDir()
+}
+
+func (s *ConvertSuite) TestFromTar(c *C) {
+	input := filepath.Join(s.folder, "input.tar")
+	c.Assert(ioutil.WriteFile(input, s.buildTar(c), 0666), IsNil)
+
+	cmd := &CmdConvert{From: "tar"}
+	cmd.Args.File = filepath.Join(s.folder, "tar.siva")
+	cmd.Archive.Path = input
+
+	c.Assert(cmd.Execute(nil), IsNil)
+	s.assertFiles(c, cmd.Args.File)
+}
+
+func (s *ConvertSuite) TestFromTarGzipStdin(c *C) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	_, err := gw.Write(s.buildTar(c))
+	c.Assert(err, IsNil)
+	c.Assert(gw.Close(), IsNil)
+
+	defaultInput = buf
+	defer func() { defaultInput = os.Stdin }()
+
+	cmd := &CmdConvert{From: "tar"}
+	cmd.Args.File = filepath.Join(s.folder, "targz.siva")
+
+	c.Assert(cmd.Execute(nil), IsNil)
+	s.assertFiles(c, cmd.Args.File)
+}
+
+func (s *ConvertSuite) TestFromTarInvalid(c *C) {
+	defaultInput = bytes.NewReader(bytes.Repeat([]byte{1}, 1024))
+	defer func() { defaultInput = os.Stdin }()
+
+	cmd := &CmdConvert{From: "tar"}
+	cmd.Args.File = filepath.Join(s.folder, "invalid.siva")
+
+	c.Assert(cmd.Execute(nil), NotNil)
+
+	_, err := os.Stat(cmd.Args.File)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *ConvertSuite) TestFromTarTruncatedAppend(c *C) {
+	path := filepath.Join(s.folder, "append.siva")
+	cmd := &CmdConvert{From: "tar"}
+	cmd.Args.File = path
+	cmd.Archive.Path = filepath.Join(s.folder, "input.tar")
+	c.Assert(ioutil.WriteFile(cmd.Archive.Path, s.buildTar(c), 0666), IsNil)
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	before, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	err = tw.WriteHeader(&tar.Header{Name: "big", Typeflag: tar.TypeReg, Mode: 0644, Size: 4096})
+	c.Assert(err, IsNil)
+	_, err = tw.Write(bytes.Repeat([]byte{'x'}, 4096))
+	c.Assert(err, IsNil)
+	c.Assert(tw.Close(), IsNil)
+
+	defaultInput = bytes.NewReader(buf.Bytes()[:2048])
+	defer func() { defaultInput = os.Stdin }()
+
+	cmd = &CmdConvert{From: "tar", Append: true}
+	cmd.Args.File = path
+	c.Assert(cmd.Execute(nil), NotNil)
+
+	after, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(after, DeepEquals, before)
+}
+
+func (s *ConvertSuite) TestValidate(c *C) {
+	cmd := &CmdConvert{}
+	cmd.Args.File = filepath.Join(s.folder, "validate.siva")
+	c.Assert(cmd.Execute(nil), NotNil)
+
+	cmd = &CmdConvert{From: "tar", To: "zip"}
+	cmd.Args.File = filepath.Join(s.folder, "validate.siva")
+	c.Assert(cmd.Execute(nil), NotNil)
+}
+
+func (s *ConvertSuite) TestZip(c *C) {
+	archive := filepath.Join(s.folder, "output.zip")
+
+	cmd := &CmdConvert{To: "zip"}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Archive.Path = archive
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	zr, err := zip.OpenReader(archive)
+	c.Assert(err, IsNil)
+	c.Assert(zr.File, HasLen, len(files))
+	c.Assert(zr.Close(), IsNil)
+
+	cmd = &CmdConvert{From: "zip"}
+	cmd.Args.File = filepath.Join(s.folder, "zip.siva")
+	cmd.Archive.Path = archive
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	s.assertFiles(c, cmd.Args.File)
+}
+
+func (s *ConvertSuite) TestZipStdin(c *C) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		c.Assert(err, IsNil)
+		_, err = io.WriteString(w, f.Body)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(zw.Close(), IsNil)
+
+	defaultInput = buf
+	defer func() { defaultInput = os.Stdin }()
+
+	cmd := &CmdConvert{From: "zip"}
+	cmd.Args.File = filepath.Join(s.folder, "zip.siva")
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	s.assertFiles(c, cmd.Args.File)
+}
+
+func (s *ConvertSuite) TestToTarStdout(c *C) {
+	cmd := &CmdConvert{To: "tar"}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	tr := tar.NewReader(bytes.NewReader([]byte(output)))
+	for _, f := range files {
+		th, err := tr.Next()
+		c.Assert(err, IsNil)
+		c.Assert(th.Name, Equals, f.Name)
+	}
+}
+
+func (s *ConvertSuite) buildTar(c *C) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     f.Name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(f.Body)),
+		})
+		c.Assert(err, IsNil)
+
+		_, err = io.WriteString(tw, f.Body)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(tw.Close(), IsNil)
+	return buf.Bytes()
+}
+
+func (s *ConvertSuite) assertFiles(c *C, path string) {
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	r := siva.NewReader(f)
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, len(files))
+
+	for j, e := range i {
+		c.Assert(e.Name, Equals, files[j].Name)
+
+		sr, err := r.Get(e)
+		c.Assert(err, IsNil)
+		content, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+		c.Assert(string(content), Equals, files[j].Body)
+	}
+}
@@ -0,0 +1,367 @@
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+const (
+	// maxDiffSize is the maximum size of the contents compared with
+	// --unified, larger entries are reported as binary.
+	maxDiffSize = 1024 * 1024
+	// maxDiffLines bounds the product of the number of lines of both
+	// contents compared with --unified.
+	maxDiffLines = 4 * 1024 * 1024
+	diffContext  = 3
+)
+
+type CmdDiff struct {
+	cmd
+	From    int  `long:"from" description:"Compares the first siva file as it was after writing the given block, numbered from 1, instead of the last one"`
+	To      int  `long:"to" description:"Compares the second siva file as it was after writing the given block, numbered from 1, instead of the last one"`
+	Unified bool `short:"u" long:"unified" description:"Shows the differences between the contents of the modified text entries"`
+	Other   struct {
+		File string `positional-arg-name:"other-siva-file" description:"siva file to compare with, if empty the first one is compared with itself using --from and --to."`
+	} `positional-args:"yes"`
+}
+
+func (c *CmdDiff) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	ra, size, err := c.buildReaderAt()
+	if err != nil {
+		return err
+	}
+
+	defer c.close()
+	a, err := snapshot(ra, size, c.From)
+	if err != nil {
+		return err
+	}
+
+	b := a
+	if c.Other.File == "" {
+		b, err = snapshot(ra, size, c.To)
+	} else {
+		var closer io.Closer
+		ra, size, closer, err = openReaderAt(c.Other.File, c.LockTimeout)
+		if err != nil {
+			return err
+		}
+
+		defer closer.Close()
+		b, err = snapshot(ra, size, c.To)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.diff(a, b)
+}
+
+func (c *CmdDiff) validate() error {
+	if err := c.cmd.validate(); err != nil {
+		return err
+	}
+
+	if c.From < 0 || c.To < 0 {
+		return fmt.Errorf("Invalid block, blocks are numbered from 1")
+	}
+
+	if c.Other.File == "" && c.From == c.To {
+		return fmt.Errorf("Please provide a second siva file, or different --from and --to blocks")
+	}
+
+	return nil
+}
+
+// snapshot returns a reader of the siva file as it was after writing the given
+// block, see blockEnd.
+func snapshot(ra io.ReaderAt, size int64, block int) (siva.Reader, error) {
+	end, err := blockEnd(ra, size, block)
+	if err != nil {
+		return nil, err
+	}
+
+	return siva.NewReaderAt(ra, end), nil
+}
+
+// openReaderAt opens a siva file other than the one of the command, holding a
+// shared lock on it if it's local.
+func openReaderAt(path string, timeout time.Duration) (io.ReaderAt, int64, io.Closer, error) {
+	if isURL(path) {
+		ra, err := siva.NewHTTPReaderAt(http.DefaultClient, path)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error opening file: %s", err)
+		}
+
+		return ra, ra.Size(), ioutil.NopCloser(nil), nil
+	}
+
+	f, err := siva.OpenFileTimeout(path, os.O_RDONLY, timeout)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("error opening file: %s", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, nil, err
+	}
+
+	return f, fi.Size(), f, nil
+}
+
+func (c *CmdDiff) diff(a, b siva.Reader) error {
+	ia, err := a.Index()
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	ib, err := b.Index()
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	changes := siva.Diff(ia, ib)
+	counts := make(map[siva.ChangeType]int)
+	for _, ch := range changes {
+		counts[ch.Type]++
+	}
+
+	fmt.Fprintf(defaultOutput, "%d added, %d removed, %d modified, %d mode changed\n",
+		counts[siva.ChangeAdded], counts[siva.ChangeRemoved],
+		counts[siva.ChangeModified], counts[siva.ChangeMode],
+	)
+
+	for _, ch := range changes {
+		switch ch.Type {
+		case siva.ChangeAdded:
+			fmt.Fprintf(defaultOutput, "A %s\n", ch.Name)
+		case siva.ChangeRemoved:
+			fmt.Fprintf(defaultOutput, "D %s\n", ch.Name)
+		case siva.ChangeModified:
+			fmt.Fprintf(defaultOutput, "M %s\n", ch.Name)
+		case siva.ChangeMode:
+			fmt.Fprintf(defaultOutput, "T %s (%s -> %s)\n", ch.Name, ch.From.Mode, ch.To.Mode)
+		}
+	}
+
+	if !c.Unified {
+		return nil
+	}
+
+	for _, ch := range changes {
+		if ch.Type == siva.ChangeMode {
+			continue
+		}
+
+		if err := c.writeUnified(a, b, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CmdDiff) writeUnified(a, b siva.Reader, ch siva.Change) error {
+	fromName, toName := "a/"+ch.Name, "b/"+ch.Name
+	from, err := readDiffContent(a, ch.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := readDiffContent(b, ch.To)
+	if err != nil {
+		return err
+	}
+
+	if ch.From == nil {
+		fromName = "/dev/null"
+	}
+
+	if ch.To == nil {
+		toName = "/dev/null"
+	}
+
+	if !isText(from) || !isText(to) {
+		fmt.Fprintf(defaultOutput, "Binary files %s and %s differ\n", fromName, toName)
+		return nil
+	}
+
+	la, lb := splitLines(string(from)), splitLines(string(to))
+	if len(la)*len(lb) > maxDiffLines {
+		fmt.Fprintf(defaultOutput, "Files %s and %s differ, too many lines to compare\n", fromName, toName)
+		return nil
+	}
+
+	fmt.Fprintf(defaultOutput, "--- %s\n+++ %s\n", fromName, toName)
+	writeHunks(defaultOutput, diffLines(la, lb))
+	return nil
+}
+
+// readDiffContent returns the content of e, empty if e is nil and nil if it's
+// too large to be compared.
+func readDiffContent(r siva.Reader, e *siva.IndexEntry) ([]byte, error) {
+	if e == nil {
+		return []byte{}, nil
+	}
+
+	if e.Size > maxDiffSize {
+		return nil, nil
+	}
+
+	content, err := r.Get(e)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %s", e.Name, err)
+	}
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %s", e.Name, err)
+	}
+
+	return data, nil
+}
+
+func isText(data []byte) bool {
+	return data != nil && bytes.IndexByte(data, 0) == -1 && utf8.Valid(data)
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the operations transforming a into b, using the longest
+// common subsequence of their lines.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	return ops
+}
+
+// writeHunks writes the changes in ops in unified format, with diffContext
+// lines of context.
+func writeHunks(w io.Writer, ops []diffOp) {
+	// positions in a and b before every operation
+	posA, posB := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		posA[k+1], posB[k+1] = posA[k], posB[k]
+		if op.kind != '+' {
+			posA[k+1]++
+		}
+
+		if op.kind != '-' {
+			posB[k+1]++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		for k < len(ops) && ops[k].kind == ' ' {
+			k++
+		}
+
+		if k == len(ops) {
+			return
+		}
+
+		start, end := max(k-diffContext, 0), k
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*diffContext {
+				break
+			}
+
+			end = next
+		}
+
+		stop := min(end+diffContext, len(ops))
+		fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(posA[start], posA[stop]-posA[start]),
+			hunkRange(posB[start], posB[stop]-posB[start]),
+		)
+
+		for _, op := range ops[start:stop] {
+			fmt.Fprintf(w, "%c%s", op.kind, op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				fmt.Fprint(w, "\n\\ No newline at end of file\n")
+			}
+		}
+
+		k = stop
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func (s *DiffSuite) TestArchives(c *C) {
+	cmd := &CmdDiff{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Other.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	c.Assert(output, Equals, "0 added, 0 removed, 0 modified, 2 mode changed\n"+
+		"T gopher.txt (-rw-r--r-- -> -rwxr-xr-x)\n"+
+		"T readme.txt (-rw-r--r-- -> -rw-------)\n")
+}
+
+func (s *DiffSuite) TestBlocks(c *C) {
+	cmd := &CmdDiff{From: 1, Unified: true}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "overwritten.siva")
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	c.Assert(strings.HasPrefix(output, "0 added, 0 removed, 3 modified, 0 mode changed\n"+
+		"M gopher.txt\n"+
+		"M readme.txt\n"+
+		"M todo.txt\n"+
+		"--- a/gopher.txt\n"+
+		"+++ b/gopher.txt\n"+
+		"@@ -1 +1,4 @@\n"+
+		"-GARBAGE\n"+
+		"+Gopher names:\n"+
+		"+George\n"+
+		"+Geoffrey\n"+
+		"+Gonzo\n"+
+		"\\ No newline at end of file\n"), Equals, true)
+}
+
+func (s *DiffSuite) TestValidate(c *C) {
+	cmd := &CmdDiff{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "overwritten.siva")
+	c.Assert(cmd.Execute(nil), ErrorMatches, "Please provide a second siva file.*")
+
+	cmd.From = 3
+	c.Assert(cmd.Execute(nil), ErrorMatches, "Invalid block 3, the archive has 2 blocks")
+}
+
+func (s *DiffSuite) TestWriteHunks(c *C) {
+	a := splitLines("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20\n")
+	b := splitLines("1\n2\nthree\n4\n5\n6\n7\n8\n9\nten\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20\n21\n")
+
+	buf := new(bytes.Buffer)
+	writeHunks(buf, diffLines(a, b))
+	c.Assert(buf.String(), Equals, "@@ -1,13 +1,13 @@\n"+
+		" 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n"+
+		"@@ -18,3 +18,4 @@\n"+
+		" 18\n 19\n 20\n+21\n")
+
+	buf.Reset()
+	writeHunks(buf, diffLines(nil, splitLines("foo\n")))
+	c.Assert(buf.String(), Equals, "@@ -0,0 +1 @@\n+foo\n")
+}
@@ -0,0 +1,126 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	"github.com/dustin/go-humanize"
+)
+
+type CmdInfo struct {
+	cmd
+	JSON bool `long:"json" description:"Writes the statistics as JSON"`
+}
+
+type infoJSON struct {
+	Size            int64       `json:"size"`
+	Blocks          int         `json:"blocks"`
+	Entries         int         `json:"entries"`
+	LiveEntries     int         `json:"live_entries"`
+	ShadowedEntries int         `json:"shadowed_entries"`
+	DeletedEntries  int         `json:"deleted_entries"`
+	LiveBytes       uint64      `json:"live_bytes"`
+	DeadBytes       uint64      `json:"dead_bytes"`
+	IndexBytes      uint64      `json:"index_bytes"`
+	Largest         []infoEntry `json:"largest"`
+	Oldest          *time.Time  `json:"oldest,omitempty"`
+	Newest          *time.Time  `json:"newest,omitempty"`
+}
+
+type infoEntry struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+func (c *CmdInfo) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	ra, size, err := c.buildReaderAt()
+	if err != nil {
+		return err
+	}
+
+	defer c.close()
+	st, err := siva.Stats(ra, size)
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	if c.JSON {
+		return c.writeJSON(st)
+	}
+
+	return c.writeText(st)
+}
+
+func (c *CmdInfo) writeJSON(st *siva.ArchiveStats) error {
+	info := &infoJSON{
+		Size:            st.Size,
+		Blocks:          st.Blocks,
+		Entries:         st.Entries,
+		LiveEntries:     st.LiveEntries,
+		ShadowedEntries: st.ShadowedEntries,
+		DeletedEntries:  st.DeletedEntries,
+		LiveBytes:       st.LiveBytes,
+		DeadBytes:       st.DeadBytes,
+		IndexBytes:      st.IndexBytes,
+		Largest:         []infoEntry{},
+	}
+
+	for _, e := range st.Largest {
+		info.Largest = append(info.Largest, infoEntry{Name: e.Name, Size: e.Size})
+	}
+
+	if !st.Oldest.IsZero() {
+		info.Oldest, info.Newest = &st.Oldest, &st.Newest
+	}
+
+	enc := json.NewEncoder(defaultOutput)
+	enc.SetIndent("", "  ")
+	return enc.Encode(info)
+}
+
+func (c *CmdInfo) writeText(st *siva.ArchiveStats) error {
+	w := tabwriter.NewWriter(defaultOutput, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(w, "Size:\t%s (%d bytes)\n", humanize.Bytes(uint64(st.Size)), st.Size)
+	fmt.Fprintf(w, "Blocks:\t%d\n", st.Blocks)
+	fmt.Fprintf(w, "Entries:\t%d (%d live, %d shadowed, %d deleted)\n",
+		st.Entries, st.LiveEntries, st.ShadowedEntries, st.DeletedEntries)
+	fmt.Fprintf(w, "Live bytes:\t%s\n", humanize.Bytes(st.LiveBytes))
+	fmt.Fprintf(w, "Dead bytes:\t%s (%s)\n", humanize.Bytes(st.DeadBytes), ratio(st.DeadBytes, uint64(st.Size)))
+	fmt.Fprintf(w, "Index bytes:\t%s (%s)\n", humanize.Bytes(st.IndexBytes), ratio(st.IndexBytes, uint64(st.Size)))
+
+	if !st.Oldest.IsZero() {
+		fmt.Fprintf(w, "Oldest:\t%s\n", st.Oldest.Format(time.RFC3339))
+		fmt.Fprintf(w, "Newest:\t%s\n", st.Newest.Format(time.RFC3339))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(st.Largest) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(defaultOutput, "Largest entries:")
+	for _, e := range st.Largest {
+		fmt.Fprintf(defaultOutput, "  % 6s %s\n", humanize.Bytes(e.Size), e.Name)
+	}
+
+	return nil
+}
+
+func ratio(n, total uint64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
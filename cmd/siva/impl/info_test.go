@@ -0,0 +1,51 @@
+package impl
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type InfoSuite struct{}
+
+var _ = Suite(&InfoSuite{})
+
+func (s *InfoSuite) TestText(c *C) {
+	cmd := &CmdInfo{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "overwritten.siva")
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	c.Assert(strings.Contains(output, "Blocks:      2\n"), Equals, true)
+	c.Assert(strings.Contains(output, "Entries:     6 (3 live, 3 shadowed, 0 deleted)\n"), Equals, true)
+	c.Assert(strings.Contains(output, "Dead bytes:  24 B (5.0%)\n"), Equals, true)
+	c.Assert(strings.HasSuffix(output, "Largest entries:\n"+
+		"    38 B readme.txt\n"+
+		"    35 B gopher.txt\n"+
+		"    28 B todo.txt\n"), Equals, true)
+}
+
+func (s *InfoSuite) TestJSON(c *C) {
+	cmd := &CmdInfo{JSON: true}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "duplicate.siva")
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	var info infoJSON
+	c.Assert(json.Unmarshal([]byte(output), &info), IsNil)
+	c.Assert(info.Size, Equals, int64(554))
+	c.Assert(info.Blocks, Equals, 2)
+	c.Assert(info.LiveEntries, Equals, 3)
+	c.Assert(info.ShadowedEntries, Equals, 3)
+	c.Assert(info.LiveBytes+info.DeadBytes+info.IndexBytes, Equals, uint64(554))
+	c.Assert(info.Largest, DeepEquals, []infoEntry{
+		{"readme.txt", 38}, {"gopher.txt", 35}, {"todo.txt", 28},
+	})
+	c.Assert(info.Oldest, NotNil)
+}
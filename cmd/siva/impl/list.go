@@ -1,9 +1,15 @@
 package impl
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
 
 	"github.com/dustin/go-humanize"
 )
@@ -12,24 +18,154 @@ var defaultOutput io.Writer = os.Stdout
 
 type CmdList struct {
 	cmd
+	Glob    string `short:"g" long:"glob" description:"Only list files matching the given glob pattern, ** matches any number of directories"`
+	Newer   string `long:"newer" description:"Only list files modified after the given date (YYYY-MM-DD or RFC3339)"`
+	Older   string `long:"older" description:"Only list files modified before the given date (YYYY-MM-DD or RFC3339)"`
+	Larger  string `long:"larger" description:"Only list files larger than the given size (e.g. 100MB)"`
+	Smaller string `long:"smaller" description:"Only list files smaller than the given size (e.g. 1KiB)"`
+	Mode    string `long:"mode" description:"Only list files with all the given octal permission bits set (e.g. 111)"`
+	Format  string `long:"format" choice:"text" choice:"json" choice:"jsonl" choice:"csv" default:"text" description:"Output format, json, jsonl and csv include the CRC32, flags, block and offset of the entries"`
+	All     bool   `short:"a" long:"all" description:"Lists all the entries in the indexes, including the overwritten and deleted ones"`
+}
+
+// listEntry is an entry as written by the json, jsonl and csv formats. Mode is
+// the numeric os.FileMode of the entry and ModeString its string form.
+type listEntry struct {
+	Name       string `json:"name"`
+	Size       uint64 `json:"size"`
+	Mode       uint32 `json:"mode"`
+	ModeString string `json:"mode_string"`
+	ModTime    string `json:"mtime"`
+	CRC32      uint32 `json:"crc32"`
+	Flags      uint32 `json:"flags"`
+	Block      int    `json:"block"`
+	Offset     uint64 `json:"offset"`
+}
+
+var listCSVHeader = []string{"name", "size", "mode", "mode_string", "mtime", "crc32", "flags", "block", "offset"}
+
+func (e *listEntry) csv() []string {
+	return []string{
+		e.Name,
+		strconv.FormatUint(e.Size, 10),
+		strconv.FormatUint(uint64(e.Mode), 10),
+		e.ModeString,
+		e.ModTime,
+		strconv.FormatUint(uint64(e.CRC32), 10),
+		strconv.FormatUint(uint64(e.Flags), 10),
+		strconv.Itoa(e.Block),
+		strconv.FormatUint(e.Offset, 10),
+	}
 }
 
 func (c *CmdList) Execute(args []string) error {
-	if err := c.buildReader(); err != nil {
+	p, err := c.buildPredicate()
+	if err != nil {
+		return err
+	}
+
+	ra, size, err := c.buildReaderAt()
+	if err != nil {
 		return err
 	}
 
 	defer c.close()
-	return c.listVolume()
+	return c.listVolume(ra, size, p)
+}
+
+func (c *CmdList) buildPredicate() (siva.Predicate, error) {
+	var ps []siva.Predicate
+	if c.Glob != "" {
+		p, err := siva.NameGlob(c.Glob)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid glob pattern %q, %s", c.Glob, err)
+		}
+
+		ps = append(ps, p)
+	}
+
+	if c.Newer != "" || c.Older != "" {
+		var from, to time.Time
+		var err error
+		if from, err = parseDate(c.Newer); err != nil {
+			return nil, err
+		}
+
+		if to, err = parseDate(c.Older); err != nil {
+			return nil, err
+		}
+
+		if !from.IsZero() {
+			from = from.Add(time.Nanosecond)
+		}
+
+		ps = append(ps, siva.ModTimeRange(from, to))
+	}
+
+	if c.Larger != "" || c.Smaller != "" {
+		var min, max uint64
+		var err error
+		if min, err = parseSize(c.Larger); err != nil {
+			return nil, err
+		}
+
+		if max, err = parseSize(c.Smaller); err != nil {
+			return nil, err
+		}
+
+		if c.Larger != "" {
+			min++
+		}
+
+		if c.Smaller != "" && max == 0 {
+			return func(*siva.IndexEntry) bool { return false }, nil
+		}
+
+		ps = append(ps, siva.SizeRange(min, max))
+	}
+
+	if c.Mode != "" {
+		mode, err := strconv.ParseUint(c.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid mode %q, %s", c.Mode, err)
+		}
+
+		ps = append(ps, siva.ModeBits(os.FileMode(mode)))
+	}
+
+	if len(ps) == 0 {
+		return nil, nil
+	}
+
+	return ps[0].And(ps[1:]...), nil
 }
 
-func (c *CmdList) listVolume() error {
-	i, err := c.r.Index()
+func (c *CmdList) listVolume(ra io.ReaderAt, size int64, p siva.Predicate) error {
+	i, err := siva.ReadRawIndex(ra, size)
 	if err != nil {
 		return fmt.Errorf("error reading index: %s", err)
 	}
 
-	for _, file := range i.Filter() {
+	blocks := make(map[uint64]int)
+	for n, offset := range i.Blocks() {
+		blocks[offset] = n + 1
+	}
+
+	entries := i
+	if !c.All {
+		entries = i.Filter()
+	}
+
+	if p != nil {
+		entries = entries.Query(p)
+	}
+
+	switch c.Format {
+	case "json", "jsonl", "csv":
+		return c.writeStructured(entries, blocks)
+	}
+
+	for _, file := range entries {
 		fmt.Fprintf(defaultOutput, "%s %s % 6s %s\n",
 			file.Mode.Perm(),
 			file.ModTime.Format("Jan 02 15:04"),
@@ -40,3 +176,77 @@ func (c *CmdList) listVolume() error {
 
 	return nil
 }
+
+func (c *CmdList) writeStructured(entries siva.Index, blocks map[uint64]int) error {
+	list := make([]*listEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, &listEntry{
+			Name:       e.Name,
+			Size:       e.Size,
+			Mode:       uint32(e.Mode),
+			ModeString: e.Mode.String(),
+			ModTime:    e.ModTime.UTC().Format(time.RFC3339Nano),
+			CRC32:      e.CRC32,
+			Flags:      uint32(e.Flags),
+			Block:      blocks[e.BlockOffset()],
+			Offset:     e.BlockOffset() + e.Start,
+		})
+	}
+
+	switch c.Format {
+	case "json":
+		enc := json.NewEncoder(defaultOutput)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "jsonl":
+		enc := json.NewEncoder(defaultOutput)
+		for _, e := range list {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	w := csv.NewWriter(defaultOutput)
+	if err := w.Write(listCSVHeader); err != nil {
+		return err
+	}
+
+	for _, e := range list {
+		if err := w.Write(e.csv()); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid date %q, use YYYY-MM-DD or RFC3339", s)
+}
+
+func parseSize(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	size, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid size %q, %s", s, err)
+	}
+
+	return size, nil
+}
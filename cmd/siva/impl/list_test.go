@@ -2,7 +2,11 @@ package impl
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 
 	. "gopkg.in/check.v1"
 )
@@ -31,3 +35,158 @@ func captureOutput(f func()) string {
 
 	return buf.String()
 }
+
+func (s *ListSuite) TestGlob(c *C) {
+	cmd := &CmdList{}
+	cmd.Args.File = "../../../fixtures/dirs.siva"
+	cmd.Glob = "**/{a,1}"
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(strings.HasSuffix(lines[0], " numbers/1"), Equals, true)
+	c.Assert(strings.HasSuffix(lines[1], " letters/a"), Equals, true)
+}
+
+func (s *ListSuite) TestFilters(c *C) {
+	tests := []struct {
+		cmd      CmdList
+		expected []string
+	}{
+		{CmdList{Mode: "100"}, []string{"gopher.txt"}},
+		{CmdList{Mode: "600"}, []string{"gopher.txt", "readme.txt", "todo.txt"}},
+		{CmdList{Larger: "35B"}, []string{"readme.txt"}},
+		{CmdList{Smaller: "35B"}, []string{"todo.txt"}},
+		{CmdList{Larger: "30B", Smaller: "36B"}, []string{"gopher.txt"}},
+		{CmdList{Newer: "2016-10-08"}, []string{"gopher.txt", "readme.txt", "todo.txt"}},
+		{CmdList{Newer: "2016-10-09"}, []string{}},
+		{CmdList{Older: "2016-10-08T09:39:31.643331896Z"}, []string{}},
+		{CmdList{Older: "2016-10-08T09:39:32Z", Mode: "044"}, []string{"gopher.txt", "todo.txt"}},
+	}
+
+	for _, t := range tests {
+		cmd := t.cmd
+		cmd.Args.File = "../../../fixtures/perms.siva"
+
+		output := captureOutput(func() {
+			err := cmd.Execute(nil)
+			c.Assert(err, IsNil)
+		})
+
+		names := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			if line != "" {
+				fields := strings.Fields(line)
+				names = append(names, fields[len(fields)-1])
+			}
+		}
+
+		c.Assert(names, DeepEquals, t.expected, Commentf("%+v", t.cmd))
+	}
+}
+
+func (s *ListSuite) TestInvalidFilters(c *C) {
+	for _, cmd := range []*CmdList{
+		{Mode: "9"},
+		{Larger: "foo"},
+		{Newer: "yesterday"},
+		{Glob: "["},
+	} {
+		cmd.Args.File = "../../../fixtures/perms.siva"
+		c.Assert(cmd.Execute(nil), NotNil)
+	}
+}
+
+func (s *ListSuite) TestFormatJSON(c *C) {
+	cmd := &CmdList{Format: "json"}
+	cmd.Args.File = "../../../fixtures/overwritten.siva"
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	var entries []listEntry
+	c.Assert(json.Unmarshal([]byte(output), &entries), IsNil)
+	c.Assert(entries, DeepEquals, []listEntry{
+		{"gopher.txt", 35, 0644, "-rw-r--r--", "2016-10-08T09:39:31.643331896Z", 2562029907, 0, 2, 200},
+		{"readme.txt", 38, 0644, "-rw-r--r--", "2016-10-08T09:39:31.643331896Z", 501858000, 0, 2, 235},
+		{"todo.txt", 28, 0644, "-rw-r--r--", "2016-10-08T09:39:31.643331896Z", 784794286, 0, 2, 273},
+	})
+}
+
+func (s *ListSuite) TestFormatJSONL(c *C) {
+	cmd := &CmdList{Format: "jsonl", All: true}
+	cmd.Args.File = "../../../fixtures/overwritten.siva"
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	c.Assert(lines, HasLen, 6)
+
+	var e listEntry
+	c.Assert(json.Unmarshal([]byte(lines[0]), &e), IsNil)
+	c.Assert(e.Name, Equals, "gopher.txt")
+	c.Assert(e.Block, Equals, 1)
+	c.Assert(e.Offset, Equals, uint64(0))
+
+	c.Assert(json.Unmarshal([]byte(lines[5]), &e), IsNil)
+	c.Assert(e.Name, Equals, "todo.txt")
+	c.Assert(e.Block, Equals, 2)
+	c.Assert(e.Offset, Equals, uint64(273))
+}
+
+func (s *ListSuite) TestFormatCSV(c *C) {
+	cmd := &CmdList{Format: "csv", Glob: "gopher.txt"}
+	cmd.Args.File = "../../../fixtures/perms.siva"
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0], DeepEquals, listCSVHeader)
+	c.Assert(records[1][0], Equals, "gopher.txt")
+	c.Assert(records[1][2], Equals, "493")
+	c.Assert(records[1][3], Equals, "-rwxr-xr-x")
+	c.Assert(records[1][7], Equals, "1")
+}
+
+func (s *ListSuite) TestAllDeleted(c *C) {
+	path := filepath.Join(c.MkDir(), "foo.siva")
+
+	pack := &CmdPack{}
+	pack.Args.File = path
+	pack.Input.Files = []string{"../../../fixtures/basic.siva"}
+	c.Assert(pack.Execute(nil), IsNil)
+
+	rm := &CmdRm{}
+	rm.Args.File = path
+	rm.Input.Patterns = []string{"**"}
+	c.Assert(rm.Execute(nil), IsNil)
+
+	for _, t := range []struct {
+		all      bool
+		expected int
+	}{{false, 0}, {true, 2}} {
+		cmd := &CmdList{Format: "jsonl", All: t.all}
+		cmd.Args.File = path
+
+		output := captureOutput(func() {
+			err := cmd.Execute(nil)
+			c.Assert(err, IsNil)
+		})
+
+		c.Assert(strings.Count(output, "\n"), Equals, t.expected)
+	}
+}
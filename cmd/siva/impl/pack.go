@@ -6,17 +6,25 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"gopkg.in/src-d/go-siva.v1"
 )
 
 type CmdPack struct {
 	cmd
-	Append bool `long:"append" description:"If append, the files are added to an existing siva file"`
-	Delete bool `long:"delete" description:"If delete, the files are deleted to an existing siva file"`
-	Input  struct {
+	Append       bool   `long:"append" description:"If append, the files are added to an existing siva file"`
+	Delete       bool   `long:"delete" description:"If delete, the files are deleted to an existing siva file"`
+	Jobs         int    `short:"j" long:"jobs" default:"1" description:"Number of files written in parallel"`
+	Reproducible bool   `long:"reproducible" description:"Sorts the inputs and normalizes modification times and permissions, the time is taken from --mtime, SOURCE_DATE_EPOCH or the Unix epoch"`
+	MTime        string `long:"mtime" description:"Modification time of the files in reproducible mode, as YYYY-MM-DD or RFC3339"`
+	Input        struct {
 		Files []string `positional-arg-name:"input" description:"files or directories to be add to the archive."`
 	} `positional-args:"yes"`
+
+	modTime time.Time
 }
 
 func (c *CmdPack) Execute(args []string) error {
@@ -24,9 +32,15 @@ func (c *CmdPack) Execute(args []string) error {
 		return err
 	}
 
+	if err := c.buildWriter(c.Append); err != nil {
+		return err
+	}
+
 	if err := c.do(); err != nil {
-		if err := os.Remove(c.Args.File); err != nil {
-			return err
+		if !c.Append {
+			if err := os.Remove(c.Args.File); err != nil {
+				return err
+			}
 		}
 
 		return err
@@ -36,10 +50,6 @@ func (c *CmdPack) Execute(args []string) error {
 }
 
 func (c *CmdPack) do() error {
-	if err := c.buildWriter(c.Append); err != nil {
-		return err
-	}
-
 	if err := c.pack(); err != nil {
 		_ = c.close()
 		return err
@@ -57,17 +67,127 @@ func (c *CmdPack) validate() error {
 		return fmt.Errorf("Invalid input count, please add one or more input files/dirs")
 	}
 
+	if c.MTime != "" && !c.Reproducible {
+		return fmt.Errorf("--mtime requires --reproducible")
+	}
+
+	if c.Reproducible {
+		return c.validateReproducible()
+	}
+
+	return nil
+}
+
+func (c *CmdPack) validateReproducible() (err error) {
+	if c.MTime != "" {
+		c.modTime, err = parseDate(c.MTime)
+		return err
+	}
+
+	c.modTime, err = siva.SourceDateEpoch()
+	if err != nil {
+		return fmt.Errorf("Invalid modification time: %s", err)
+	}
+
 	return nil
 }
 
+// pack reserves the space of every file in the order they are found, and
+// writes their contents with c.Jobs goroutines.
 func (c *CmdPack) pack() error {
-	for _, file := range c.Input.Files {
+	w, err := c.f.WriterAt()
+	if err != nil {
+		return err
+	}
+
+	workers := c.Jobs
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan packJob, workers)
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		firstErr error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				mutex.Lock()
+				failed := firstErr != nil
+				mutex.Unlock()
+				if failed {
+					continue
+				}
+
+				if err := c.writeFile(j.fullpath, j.w); err != nil {
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	err = c.walk(func(fullpath string, fi os.FileInfo) error {
+		// deletion markers have no content
+		if c.Delete {
+			ew, err := w.WriteHeader(c.fileHeader(fullpath, fi), 0)
+			if err != nil {
+				return err
+			}
+
+			return ew.Close()
+		}
+
+		ew, err := w.WriteHeader(c.fileHeader(fullpath, fi), fi.Size())
+		if err != nil {
+			return err
+		}
+
+		jobs <- packJob{fullpath, ew}
+		return nil
+	})
+
+	close(jobs)
+	wg.Wait()
+
+	if err == nil {
+		err = firstErr
+	}
+
+	if err != nil {
+		if errA := w.Abort(); errA != nil {
+			return fmt.Errorf("%s, and %s", err, errA)
+		}
+
+		return err
+	}
+
+	return w.Close()
+}
+
+func (c *CmdPack) walk(fn packFunc) error {
+	inputs := c.Input.Files
+	if c.Reproducible {
+		inputs = append([]string(nil), inputs...)
+		sort.Strings(inputs)
+	}
+
+	for _, file := range inputs {
 		fi, err := os.Stat(file)
 		if err != nil {
 			return fmt.Errorf("Invalid input file/dir %q, no such file", file)
 		}
 
-		if err := c.packPath(file, fi); err != nil {
+		if err := c.packPath(file, fi, fn); err != nil {
 			return err
 		}
 	}
@@ -75,19 +195,28 @@ func (c *CmdPack) pack() error {
 	return nil
 }
 
-func (c *CmdPack) packPath(fullpath string, fi os.FileInfo) error {
+type packFunc func(fullpath string, fi os.FileInfo) error
+
+func (c *CmdPack) packPath(fullpath string, fi os.FileInfo, fn packFunc) error {
 	if fi.Mode().IsDir() {
-		return c.packDir(fullpath)
+		return c.packDir(fullpath, fn)
 	}
 
 	if !fi.Mode().IsRegular() {
 		return nil
 	}
 
-	return c.packFile(fullpath, fi)
+	if os.SameFile(c.fi, fi) {
+		fmt.Fprintf(os.Stderr,
+			"skipping %q, cannot archive the target file\n", fullpath)
+		return nil
+	}
+
+	c.println(fullpath)
+	return fn(fullpath, fi)
 }
 
-func (c *CmdPack) packDir(fullpath string) error {
+func (c *CmdPack) packDir(fullpath string, fn packFunc) error {
 	fis, err := ioutil.ReadDir(fullpath)
 	if err != nil {
 		return err
@@ -95,7 +224,7 @@ func (c *CmdPack) packDir(fullpath string) error {
 
 	for _, fi := range fis {
 		p := filepath.Join(fullpath, fi.Name())
-		err := c.packPath(p, fi)
+		err := c.packPath(p, fi, fn)
 		if err != nil {
 			return err
 		}
@@ -104,22 +233,12 @@ func (c *CmdPack) packDir(fullpath string) error {
 	return nil
 }
 
-func (c *CmdPack) packFile(fullpath string, fi os.FileInfo) error {
-	if os.SameFile(c.fi, fi) {
-		fmt.Fprintf(os.Stderr,
-			"skipping %q, cannot archive the target file\n", fullpath)
-		return nil
-	}
-
-	c.println(fullpath)
-	if err := c.writeFileHeader(fullpath, fi); err != nil {
-		return nil
-	}
-
-	return c.writeFile(fullpath, fi)
+type packJob struct {
+	fullpath string
+	w        io.WriteCloser
 }
 
-func (c *CmdPack) writeFileHeader(fullpath string, fi os.FileInfo) error {
+func (c *CmdPack) fileHeader(fullpath string, fi os.FileInfo) *siva.Header {
 	h := &siva.Header{
 		Name:    siva.ToSafePath(fullpath),
 		Mode:    fi.Mode(),
@@ -130,28 +249,23 @@ func (c *CmdPack) writeFileHeader(fullpath string, fi os.FileInfo) error {
 		h.Flags = siva.FlagDeleted
 	}
 
-	if err := c.w.WriteHeader(h); err != nil {
-		return err
+	if c.Reproducible {
+		return siva.NormalizeHeader(h, c.modTime)
 	}
 
-	return nil
+	return h
 }
 
-func (c *CmdPack) writeFile(fullpath string, fi os.FileInfo) error {
+func (c *CmdPack) writeFile(fullpath string, w io.WriteCloser) error {
 	f, err := os.Open(fullpath)
 	if err != nil {
 		return err
 	}
 
 	defer f.Close()
-	n, err := io.Copy(c.w, f)
-	if err != nil {
+	if _, err := io.Copy(w, f); err != nil {
 		return err
 	}
 
-	if n != fi.Size() {
-		return fmt.Errorf("unexpected bytes written")
-	}
-
-	return c.w.Flush()
+	return w.Close()
 }
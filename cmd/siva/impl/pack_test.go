@@ -1,10 +1,14 @@
 package impl
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"gopkg.in/src-d/go-siva.v1"
 
@@ -112,6 +116,93 @@ func (s *PackSuite) TestDir(c *C) {
 	c.Assert(f.Close(), IsNil)
 }
 
+func (s *PackSuite) TestParallel(c *C) {
+	cmd := &CmdPack{}
+	cmd.Args.File = filepath.Join(s.folder, "sequential.siva")
+	cmd.Input.Files = []string{filepath.Join(s.folder, "files")}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	cmd.Args.File = filepath.Join(s.folder, "parallel.siva")
+	cmd.Jobs = 4
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	sequential, err := ioutil.ReadFile(filepath.Join(s.folder, "sequential.siva"))
+	c.Assert(err, IsNil)
+	parallel, err := ioutil.ReadFile(cmd.Args.File)
+	c.Assert(err, IsNil)
+	c.Assert(parallel, DeepEquals, sequential)
+}
+
+func (s *PackSuite) TestParallelMissingInput(c *C) {
+	cmd := &CmdPack{Jobs: 4}
+	cmd.Args.File = filepath.Join(s.folder, "parallel.siva")
+	cmd.Input.Files = append(s.files, filepath.Join(s.folder, "missing"))
+	c.Assert(cmd.Execute(nil), NotNil)
+
+	_, err := os.Stat(cmd.Args.File)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *PackSuite) TestReproducible(c *C) {
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+	os.Setenv("SOURCE_DATE_EPOCH", "1500000000")
+
+	cmd := &CmdPack{Reproducible: true}
+	cmd.Args.File = filepath.Join(s.folder, "first.siva")
+	cmd.Input.Files = s.files
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	for i, file := range s.files {
+		mtime := time.Now().Add(time.Duration(i) * time.Hour)
+		c.Assert(os.Chtimes(file, mtime, mtime), IsNil)
+		c.Assert(os.Chmod(file, 0600), IsNil)
+	}
+
+	cmd = &CmdPack{Reproducible: true, Jobs: 4}
+	cmd.Args.File = filepath.Join(s.folder, "second.siva")
+	cmd.Input.Files = []string{s.files[2], s.files[0], s.files[1]}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	first, err := ioutil.ReadFile(filepath.Join(s.folder, "first.siva"))
+	c.Assert(err, IsNil)
+	second, err := ioutil.ReadFile(cmd.Args.File)
+	c.Assert(err, IsNil)
+	c.Assert(second, DeepEquals, first)
+
+	f, err := siva.OpenFile(cmd.Args.File, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+	for n, e := range i {
+		c.Assert(e.Name, Equals, siva.ToSafePath(s.files[n]))
+		c.Assert(e.ModTime.Unix(), Equals, int64(1500000000))
+		c.Assert(e.Mode, Equals, os.FileMode(0644))
+	}
+}
+
+func (s *PackSuite) TestReproducibleMTime(c *C) {
+	cmd := &CmdPack{MTime: "2020-01-01"}
+	cmd.Args.File = filepath.Join(s.folder, "mtime.siva")
+	cmd.Input.Files = s.files
+	c.Assert(cmd.Execute(nil), ErrorMatches, "--mtime requires --reproducible")
+
+	cmd.Reproducible = true
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	f, err := siva.OpenFile(cmd.Args.File, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	for _, e := range i {
+		c.Assert(e.ModTime.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), Equals, true)
+	}
+}
+
 func (s *PackSuite) TestAppend(c *C) {
 	cmd := &CmdPack{}
 
@@ -145,6 +236,80 @@ func (s *PackSuite) TestAppend(c *C) {
 	c.Assert(f.Close(), IsNil)
 }
 
+func (s *PackSuite) TestAppendError(c *C) {
+	cmd := &CmdPack{}
+	cmd.Args.File = filepath.Join(s.folder, "append-error.siva")
+	cmd.Input.Files = s.files
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	before, err := ioutil.ReadFile(cmd.Args.File)
+	c.Assert(err, IsNil)
+
+	cmd.Input.Files = []string{filepath.Join(s.folder, "missing")}
+	cmd.Append = true
+	c.Assert(cmd.Execute(nil), NotNil)
+
+	after, err := ioutil.ReadFile(cmd.Args.File)
+	c.Assert(err, IsNil)
+	c.Assert(after, DeepEquals, before)
+}
+
+func (s *PackSuite) TestDelete(c *C) {
+	cmd := &CmdPack{}
+	cmd.Args.File = filepath.Join(s.folder, "delete.siva")
+	cmd.Input.Files = s.files
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	cmd.Input.Files = s.files[0:1]
+	cmd.Append = true
+	cmd.Delete = true
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	f, err := os.Open(cmd.Args.File)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	fi, err := f.Stat()
+	c.Assert(err, IsNil)
+	i, err := siva.ReadRawIndex(f, fi.Size())
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 4)
+	c.Assert(i[3].Flags, Equals, siva.FlagDeleted)
+	c.Assert(i[3].Size, Equals, uint64(0))
+	c.Assert(i.Filter(), HasLen, 2)
+}
+
+func (s *PackSuite) TestLockTimeout(c *C) {
+	cmd := &CmdPack{}
+	cmd.Args.File = filepath.Join(s.folder, "locked.siva")
+	cmd.Input.Files = s.files[1:]
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	f, err := siva.OpenFile(cmd.Args.File, os.O_RDWR)
+	c.Assert(err, IsNil)
+
+	cmd.Input.Files = s.files[0:1]
+	cmd.Append = true
+	cmd.LockTimeout = 50 * time.Millisecond
+	err = cmd.Execute(nil)
+	c.Assert(err, NotNil)
+
+	c.Assert(f.Close(), IsNil)
+
+	err = cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	f, err = siva.OpenFile(cmd.Args.File, os.O_RDONLY)
+	c.Assert(err, IsNil)
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+
+	c.Assert(f.Close(), IsNil)
+}
+
 func (s *PackSuite) TestCleanPaths(c *C) {
 	cmd := &CmdPack{}
 
@@ -187,3 +352,148 @@ var files = []fileFixture{
 	{"readme.txt", "This archive contains some text files."},
 	{"todo.txt", "Get animal handling license."},
 }
+
+func BenchmarkPack(b *testing.B) {
+	benchmarkPack(b, 1)
+}
+
+func BenchmarkPackParallel(b *testing.B) {
+	benchmarkPack(b, 8)
+}
+
+// BenchmarkPackParallelWriter packs the same files as BenchmarkPackParallel,
+// staging them with a ParallelWriter instead of writing them in place.
+func BenchmarkPackParallelWriter(b *testing.B) {
+	dir, input, size := benchmarkInput(b)
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := packParallelWriter(filepath.Join(dir, "bench.siva"), input, 8); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkPack(b *testing.B, jobs int) {
+	dir, input, size := benchmarkInput(b)
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cmd := &CmdPack{Jobs: jobs}
+		cmd.Args.File = filepath.Join(dir, "bench.siva")
+		cmd.Input.Files = []string{input}
+		if err := cmd.Execute(nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkInput creates the directory packed by the benchmarks, returning
+// the temporary directory, the input directory and the size of its files.
+func benchmarkInput(b *testing.B) (string, string, int64) {
+	dir := b.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.Mkdir(input, 0755); err != nil {
+		b.Fatal(err)
+	}
+
+	content := make([]byte, 256*1024)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	files := 64
+	for i := 0; i < files; i++ {
+		path := filepath.Join(input, fmt.Sprintf("file-%02d", i))
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return dir, input, int64(files * len(content))
+}
+
+func packParallelWriter(path, input string, jobs int) error {
+	fis, err := ioutil.ReadDir(input)
+	if err != nil {
+		return err
+	}
+
+	f, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	pw := siva.NewParallelWriter(f)
+	names := make(chan os.FileInfo)
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		firstErr error
+	)
+
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fi := range names {
+				err := stageFile(pw, filepath.Join(input, fi.Name()), fi)
+				if err != nil {
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, fi := range fis {
+		names <- fi
+	}
+
+	close(names)
+	wg.Wait()
+
+	if firstErr != nil {
+		_ = pw.Abort()
+		_ = f.Close()
+		return firstErr
+	}
+
+	if err := pw.Close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func stageFile(pw *siva.ParallelWriter, path string, fi os.FileInfo) error {
+	w, err := pw.Create(&siva.Header{
+		Name:    path,
+		Mode:    fi.Mode(),
+		ModTime: fi.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type RemoteSuite struct {
+	server *httptest.Server
+}
+
+var _ = Suite(&RemoteSuite{})
+
+func (s *RemoteSuite) SetUpSuite(c *C) {
+	fixtures := filepath.Join("..", "..", "..", "fixtures")
+	s.server = httptest.NewServer(http.FileServer(http.Dir(fixtures)))
+}
+
+func (s *RemoteSuite) TearDownSuite(c *C) {
+	s.server.Close()
+}
+
+func (s *RemoteSuite) TestList(c *C) {
+	cmd := &CmdList{}
+	cmd.Args.File = s.server.URL + "/perms.siva"
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	c.Assert(output, HasLen, 124)
+}
+
+func (s *RemoteSuite) TestUnpack(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Args.File = s.server.URL + "/basic.siva"
+	cmd.ToStdout = true
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	for _, f := range files {
+		c.Assert(strings.Contains(output, f.Body), Equals, true)
+	}
+}
+
+func (s *RemoteSuite) TestCat(c *C) {
+	cmd := &CmdCat{Verify: true, At: 1}
+	cmd.Args.File = s.server.URL + "/overwritten.siva"
+	cmd.Input.Paths = []string{"gopher.txt"}
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	c.Assert(output, Equals, "GARBAGE\n")
+}
+
+func (s *RemoteSuite) TestNotFound(c *C) {
+	cmd := &CmdList{}
+	cmd.Args.File = s.server.URL + "/missing.siva"
+
+	c.Assert(cmd.Execute(nil), NotNil)
+}
@@ -0,0 +1,132 @@
+package impl
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+type CmdRm struct {
+	cmd
+	Regexp bool `short:"r" long:"regexp" description:"Interprets the patterns as regular expressions instead of glob patterns"`
+	DryRun bool `short:"n" long:"dry-run" description:"Prints the entries that would be deleted without modifying the archive"`
+	Input  struct {
+		Patterns []string `positional-arg-name:"pattern" required:"1" description:"glob patterns, or regular expressions with --regexp, of the entries to delete."`
+	} `positional-args:"yes"`
+
+	predicates []siva.Predicate
+}
+
+func (c *CmdRm) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	if c.DryRun {
+		if err := c.buildReader(); err != nil {
+			return err
+		}
+	} else {
+		if err := c.buildWriter(true); err != nil {
+			return err
+		}
+
+		c.r = c.f
+	}
+
+	if err := c.rm(); err != nil {
+		_ = c.abort()
+		return err
+	}
+
+	return c.close()
+}
+
+func (c *CmdRm) validate() error {
+	if err := c.cmd.validate(); err != nil {
+		return err
+	}
+
+	if len(c.Input.Patterns) == 0 {
+		return fmt.Errorf("Invalid pattern count, please add one or more patterns")
+	}
+
+	c.predicates = nil
+	for _, pattern := range c.Input.Patterns {
+		p, err := c.buildPredicate(pattern)
+		if err != nil {
+			return err
+		}
+
+		c.predicates = append(c.predicates, p)
+	}
+
+	return nil
+}
+
+func (c *CmdRm) buildPredicate(pattern string) (siva.Predicate, error) {
+	if !c.Regexp {
+		p, err := siva.NameGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid glob pattern %q, %s", pattern, err)
+		}
+
+		return p, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid regexp %q, %s", pattern, err)
+	}
+
+	return siva.NameRegexp(re), nil
+}
+
+func (c *CmdRm) rm() error {
+	i, err := c.r.Index()
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	i = i.Filter()
+	var deleted siva.Index
+	seen := make(map[string]bool)
+	for n, p := range c.predicates {
+		matches := i.Query(p)
+		if len(matches) == 0 {
+			return fmt.Errorf("No entries matching %q", c.Input.Patterns[n])
+		}
+
+		for _, e := range matches {
+			if !seen[e.Name] {
+				seen[e.Name] = true
+				deleted = append(deleted, e)
+			}
+		}
+	}
+
+	for _, e := range deleted {
+		if c.DryRun {
+			fmt.Fprintln(defaultOutput, e.Name)
+			continue
+		}
+
+		c.println(e.Name)
+		if err := c.writeDeleted(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CmdRm) writeDeleted(e *siva.IndexEntry) error {
+	h := e.Header
+	h.Flags |= siva.FlagDeleted
+	if err := c.w.WriteHeader(&h); err != nil {
+		return err
+	}
+
+	return c.w.Flush()
+}
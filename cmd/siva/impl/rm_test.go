@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type RmSuite struct {
+	file string
+}
+
+var _ = Suite(&RmSuite{})
+
+func (s *RmSuite) SetUpTest(c *C) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "fixtures", "dirs.siva"))
+	c.Assert(err, IsNil)
+
+	s.file = filepath.Join(c.MkDir(), "dirs.siva")
+	c.Assert(ioutil.WriteFile(s.file, data, 0666), IsNil)
+}
+
+func (s *RmSuite) TestGlob(c *C) {
+	cmd := &CmdRm{}
+	cmd.Args.File = s.file
+	cmd.Input.Patterns = []string{"letters/*", "file.txt"}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	c.Assert(s.names(c), DeepEquals, []string{"numbers/1", "numbers/2", "numbers/3"})
+	s.assertDeletedBlock(c, 4)
+}
+
+func (s *RmSuite) TestRegexp(c *C) {
+	cmd := &CmdRm{Regexp: true}
+	cmd.Args.File = s.file
+	cmd.Input.Patterns = []string{"^numbers/[12]$", "^numbers/1$"}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	c.Assert(s.names(c), DeepEquals, []string{
+		"file.txt", "letters/a", "letters/b", "letters/c", "numbers/3",
+	})
+	s.assertDeletedBlock(c, 2)
+}
+
+func (s *RmSuite) TestDryRun(c *C) {
+	before, err := ioutil.ReadFile(s.file)
+	c.Assert(err, IsNil)
+
+	cmd := &CmdRm{DryRun: true}
+	cmd.Args.File = s.file
+	cmd.Input.Patterns = []string{"**/{a,1}"}
+
+	output := captureOutput(func() {
+		c.Assert(cmd.Execute(nil), IsNil)
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	sort.Strings(lines)
+	c.Assert(lines, DeepEquals, []string{"letters/a", "numbers/1"})
+
+	after, err := ioutil.ReadFile(s.file)
+	c.Assert(err, IsNil)
+	c.Assert(after, DeepEquals, before)
+}
+
+func (s *RmSuite) TestNoMatches(c *C) {
+	before, err := ioutil.ReadFile(s.file)
+	c.Assert(err, IsNil)
+
+	cmd := &CmdRm{}
+	cmd.Args.File = s.file
+	cmd.Input.Patterns = []string{"letters/*", "missing"}
+	c.Assert(cmd.Execute(nil), ErrorMatches, `No entries matching "missing"`)
+
+	after, err := ioutil.ReadFile(s.file)
+	c.Assert(err, IsNil)
+	c.Assert(after, DeepEquals, before)
+}
+
+func (s *RmSuite) TestInvalidPattern(c *C) {
+	cmd := &CmdRm{Regexp: true}
+	cmd.Args.File = s.file
+	cmd.Input.Patterns = []string{"("}
+	c.Assert(cmd.Execute(nil), ErrorMatches, `Invalid regexp "\(".*`)
+}
+
+func (s *RmSuite) names(c *C) []string {
+	f, err := siva.OpenFile(s.file, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+
+	var names []string
+	for _, e := range i.Filter() {
+		names = append(names, e.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (s *RmSuite) assertDeletedBlock(c *C, count int) {
+	fi, err := os.Stat(s.file)
+	c.Assert(err, IsNil)
+
+	f, err := os.Open(s.file)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err := siva.ReadRawIndex(f, fi.Size())
+	c.Assert(err, IsNil)
+
+	blocks := i.Blocks()
+	last := i.Query(siva.BlockRange(blocks[len(blocks)-1], uint64(fi.Size())))
+	c.Assert(last, HasLen, count)
+	for _, e := range last {
+		c.Assert(e.Flags&siva.FlagDeleted, Equals, siva.FlagDeleted)
+		c.Assert(e.Size, Equals, uint64(0))
+	}
+}
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+type CmdServe struct {
+	cmd
+	Addr string `long:"addr" default:"localhost:8080" description:"Address to listen on"`
+}
+
+func (c *CmdServe) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	r, closer, err := c.openReader()
+	if err != nil {
+		return err
+	}
+
+	defer closer.Close()
+
+	if _, err := r.Index(); err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	c.println("serving", c.Args.File, "on", c.Addr)
+	return http.ListenAndServe(c.Addr, siva.HTTPHandler(r))
+}
+
+// openReader opens the siva file without locking it, a server would block any
+// append while running.
+func (c *CmdServe) openReader() (siva.Reader, io.Closer, error) {
+	if isURL(c.Args.File) {
+		if err := c.buildReader(); err != nil {
+			return nil, nil, err
+		}
+
+		return c.r, ioutil.NopCloser(nil), nil
+	}
+
+	f, err := os.Open(c.Args.File)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %s", err)
+	}
+
+	return siva.NewReader(f), f, nil
+}
@@ -0,0 +1,25 @@
+package impl
+
+import (
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type ServeSuite struct{}
+
+var _ = Suite(&ServeSuite{})
+
+func (s *ServeSuite) TestInvalidFile(c *C) {
+	cmd := &CmdServe{Addr: "localhost:0"}
+	cmd.Args.File = filepath.Join(c.MkDir(), "missing.siva")
+
+	c.Assert(cmd.Execute(nil), NotNil)
+}
+
+func (s *ServeSuite) TestInvalidAddr(c *C) {
+	cmd := &CmdServe{Addr: "invalid:address:0"}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+
+	c.Assert(cmd.Execute(nil), NotNil)
+}
@@ -2,7 +2,11 @@ package impl
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/src-d/go-siva.v1"
 
@@ -13,7 +17,14 @@ func Main() {
 	parser := flags.NewNamedParser("siva", flags.Default)
 	parser.AddCommand("pack", "Create a new archive containing the specified items.", "", &CmdPack{})
 	parser.AddCommand("unpack", "Extract to disk from the archive.", "", &CmdUnpack{})
+	parser.AddCommand("diff", "Show the differences between two files, or two blocks of a file.", "", &CmdDiff{})
+	parser.AddCommand("info", "Show statistics about the blocks and entries of a file.", "", &CmdInfo{})
 	parser.AddCommand("list", "List the items contained on a file.", "", &CmdList{})
+	parser.AddCommand("cat", "Write the contents of the given items to the standard output.", "", &CmdCat{})
+	parser.AddCommand("convert", "Convert archives between siva and other formats.", "", &CmdConvert{})
+	parser.AddCommand("rm", "Delete the items matching the given patterns from an archive.", "", &CmdRm{})
+	parser.AddCommand("serve", "Serve the items contained on a file over HTTP.", "", &CmdServe{})
+	parser.AddCommand("sync", "Update an archive with the changes of a directory.", "", &CmdSync{})
 	parser.AddCommand("version", "Show the version information.", "", &CmdVersion{})
 
 	_, err := parser.Parse()
@@ -27,12 +38,13 @@ func Main() {
 }
 
 type cmd struct {
-	Verbose bool `short:"v" description:"Activates the verbose mode"`
-	Args    struct {
+	Verbose     bool          `short:"v" description:"Activates the verbose mode"`
+	LockTimeout time.Duration `long:"lock-timeout" description:"Maximum time to wait for the siva file lock, waits forever if zero"`
+	Args        struct {
 		File string `positional-arg-name:"siva-file" required:"true" description:"siva file."`
 	} `positional-args:"yes"`
 
-	f  *os.File
+	f  *siva.File
 	fi os.FileInfo
 	r  siva.Reader
 	w  siva.Writer
@@ -45,25 +57,82 @@ func (c *cmd) validate() error {
 	return nil
 }
 
+// buildReader opens the siva file for reading holding a shared lock, if the
+// file is an HTTP URL it's read remotely using range requests.
 func (c *cmd) buildReader() (err error) {
-	c.f, err = os.Open(c.Args.File)
+	if isURL(c.Args.File) {
+		c.r, err = siva.OpenURL(c.Args.File)
+		if err != nil {
+			return fmt.Errorf("error opening url: %s", err)
+		}
+
+		return nil
+	}
+
+	c.f, err = siva.OpenFileTimeout(c.Args.File, os.O_RDONLY, c.LockTimeout)
 	if err != nil {
 		return fmt.Errorf("error opening file: %s", err)
 	}
 
-	c.r = siva.NewReader(c.f)
+	c.r = c.f
 	return nil
 }
 
+// buildReaderAt opens the siva file for reading holding a shared lock, as
+// buildReader, returning it as an io.ReaderAt along with its size.
+func (c *cmd) buildReaderAt() (io.ReaderAt, int64, error) {
+	if isURL(c.Args.File) {
+		ra, err := siva.NewHTTPReaderAt(http.DefaultClient, c.Args.File)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error opening file: %s", err)
+		}
+
+		return ra, ra.Size(), nil
+	}
+
+	if err := c.buildReader(); err != nil {
+		return nil, 0, err
+	}
+
+	fi, err := c.f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return c.f, fi.Size(), nil
+}
+
+// blockEnd returns the end of the given block of a siva file, numbered from 1,
+// or size if block is 0.
+func blockEnd(ra io.ReaderAt, size int64, block int) (int64, error) {
+	if block == 0 {
+		return size, nil
+	}
+
+	i, err := siva.ReadRawIndex(ra, size)
+	if err != nil {
+		return 0, fmt.Errorf("error reading index: %s", err)
+	}
+
+	blocks := i.Blocks()
+	if block > len(blocks) {
+		return 0, fmt.Errorf("Invalid block %d, the archive has %d blocks", block, len(blocks))
+	}
+
+	if block == len(blocks) {
+		return size, nil
+	}
+
+	return int64(blocks[block]), nil
+}
+
 func (c *cmd) buildWriter(append bool) (err error) {
 	flags := os.O_WRONLY
-	if append {
-		flags |= os.O_APPEND
-	} else {
+	if !append {
 		flags |= os.O_CREATE | os.O_TRUNC
 	}
 
-	c.f, err = os.OpenFile(c.Args.File, flags, 0666)
+	c.f, err = siva.OpenFileTimeout(c.Args.File, flags, c.LockTimeout)
 	if err != nil {
 		return fmt.Errorf("error creating file: %s", err)
 	}
@@ -74,7 +143,7 @@ func (c *cmd) buildWriter(append bool) (err error) {
 		return err
 	}
 
-	c.w = siva.NewWriter(c.f)
+	c.w = c.f
 	return nil
 }
 
@@ -86,18 +155,29 @@ func (c *cmd) println(a ...interface{}) {
 	fmt.Println(a...)
 }
 
-func (c *cmd) close() (err error) {
-	defer func() {
-		if errC := c.f.Close(); errC != nil && err == nil {
-			err = errC
-		}
-	}()
+func (c *cmd) close() error {
+	if c.f == nil {
+		return nil
+	}
 
-	if c.w != nil {
-		if err = c.w.Close(); err != nil {
-			return
-		}
+	return c.f.Close()
+}
+
+// abort discards the entries written since the file was opened and closes it,
+// leaving the file as it was.
+func (c *cmd) abort() error {
+	if c.f == nil {
+		return nil
 	}
 
-	return
+	err := c.f.Abort()
+	if errC := c.f.Close(); errC != nil && err == nil {
+		err = errC
+	}
+
+	return err
+}
+
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
 }
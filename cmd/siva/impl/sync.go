@@ -0,0 +1,69 @@
+package impl
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/src-d/go-siva.v1"
+)
+
+type CmdSync struct {
+	cmd
+	Input struct {
+		Dir string `positional-arg-name:"dir" required:"true" description:"directory to synchronize into the archive."`
+	} `positional-args:"yes"`
+}
+
+func (c *CmdSync) Execute(args []string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	var err error
+	c.f, err = siva.OpenFileTimeout(c.Args.File, os.O_RDWR|os.O_CREATE, c.LockTimeout)
+	if err != nil {
+		return fmt.Errorf("error opening file: %s", err)
+	}
+
+	if err := c.sync(); err != nil {
+		_ = c.abort()
+		return err
+	}
+
+	return c.close()
+}
+
+func (c *CmdSync) validate() error {
+	if err := c.cmd.validate(); err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(c.Input.Dir)
+	if err != nil {
+		return fmt.Errorf("Invalid input dir %q, no such directory", c.Input.Dir)
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("Invalid input dir %q, not a directory", c.Input.Dir)
+	}
+
+	return nil
+}
+
+func (c *CmdSync) sync() error {
+	i, err := c.f.Index()
+	if err != nil {
+		return fmt.Errorf("error reading index: %s", err)
+	}
+
+	events, err := siva.Sync(c.f, i, c.Input.Dir)
+	for _, e := range events {
+		c.println(e.Type, e.Name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error synchronizing %q: %s", c.Input.Dir, err)
+	}
+
+	return nil
+}
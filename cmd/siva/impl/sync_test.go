@@ -0,0 +1,69 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type SyncSuite struct {
+	folder string
+}
+
+var _ = Suite(&SyncSuite{})
+
+func (s *SyncSuite) SetUpTest(c *C) {
+	s.folder = c.MkDir()
+
+	input := filepath.Join(s.folder, "input")
+	c.Assert(os.Mkdir(input, 0755), IsNil)
+	for _, f := range files {
+		err := ioutil.WriteFile(filepath.Join(input, f.Name), []byte(f.Body), 0644)
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *SyncSuite) TestSync(c *C) {
+	cmd := &CmdSync{}
+	cmd.Args.File = filepath.Join(s.folder, "sync.siva")
+	cmd.Input.Dir = filepath.Join(s.folder, "input")
+
+	c.Assert(cmd.Execute(nil), IsNil)
+	s.assertEntries(c, cmd.Args.File, 3)
+
+	fi, err := os.Stat(cmd.Args.File)
+	c.Assert(err, IsNil)
+
+	c.Assert(cmd.Execute(nil), IsNil)
+	unchanged, err := os.Stat(cmd.Args.File)
+	c.Assert(err, IsNil)
+	c.Assert(unchanged.Size(), Equals, fi.Size())
+
+	c.Assert(os.Remove(filepath.Join(cmd.Input.Dir, files[0].Name)), IsNil)
+	c.Assert(cmd.Execute(nil), IsNil)
+	s.assertEntries(c, cmd.Args.File, 2)
+}
+
+func (s *SyncSuite) TestValidate(c *C) {
+	cmd := &CmdSync{}
+	cmd.Args.File = filepath.Join(s.folder, "sync.siva")
+	cmd.Input.Dir = filepath.Join(s.folder, "missing")
+	c.Assert(cmd.Execute(nil), ErrorMatches, "Invalid input dir .*, no such directory")
+
+	cmd.Input.Dir = filepath.Join(s.folder, "input", files[0].Name)
+	c.Assert(cmd.Execute(nil), ErrorMatches, "Invalid input dir .*, not a directory")
+}
+
+func (s *SyncSuite) assertEntries(c *C, path string, count int) {
+	f, err := siva.OpenFile(path, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i.Filter(), HasLen, count)
+}
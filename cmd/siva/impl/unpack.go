@@ -1,11 +1,15 @@
 package impl
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/src-d/go-siva.v1"
@@ -21,6 +25,13 @@ type CmdUnpack struct {
 	Overwrite   bool   `short:"o" description:"Overwrites the files if already exists"`
 	IgnorePerms bool   `short:"i" description:"Ignore files permisisions"`
 	Match       string `short:"m" description:"Only extract files matching the given regexp"`
+	Glob        string `short:"g" long:"glob" description:"Only extract files matching the given glob pattern, ** matches any number of directories"`
+	ToStdout    bool   `short:"O" long:"to-stdout" description:"Writes the files to the standard output instead of to disk"`
+	Format      string `long:"format" choice:"tar" description:"Format of the standard output, by default the contents of the files are concatenated"`
+	Gzip        bool   `short:"z" long:"gzip" description:"Compresses the standard output with gzip"`
+	NoSamePerms bool   `long:"no-same-permissions" description:"Applies the umask to the permissions of the files instead of restoring them exactly"`
+	SpecialBits bool   `long:"special-bits" description:"Restores the setuid, setgid and sticky bits of the files"`
+	Touch       bool   `long:"touch" description:"Doesn't restore the modification time of the files"`
 
 	Output struct {
 		Path string `positional-arg-name:"target" description:"taget directory"`
@@ -50,10 +61,18 @@ func (c *CmdUnpack) validate() error {
 		return err
 	}
 
-	if _, err := os.Stat(c.Args.File); err != nil {
+	if _, err := os.Stat(c.Args.File); err != nil && !isURL(c.Args.File) {
 		return fmt.Errorf("Invalid input file %q, %s\n", c.Args.File, err)
 	}
 
+	if (c.Format != "" || c.Gzip) && !c.ToStdout {
+		return fmt.Errorf("--format and --gzip can only be used with --to-stdout")
+	}
+
+	if c.SpecialBits && (c.IgnorePerms || c.NoSamePerms) {
+		return fmt.Errorf("--special-bits can't be used with -i or --no-same-permissions")
+	}
+
 	if c.Output.Path == "" {
 		c.Output.Path = "."
 	}
@@ -91,12 +110,55 @@ func (c *CmdUnpack) do() error {
 		return err
 	}
 
-	for _, entry := range i.Filter() {
-		if !c.matchingFunc(entry.Name) {
-			continue
+	entries := i.Filter()
+	if c.Glob != "" {
+		entries, err = entries.Glob(c.Glob)
+		if err != nil {
+			return fmt.Errorf("Invalid glob pattern %q, %s\n", c.Glob, err)
+		}
+	}
+
+	var matches siva.Index
+	for _, entry := range entries {
+		if c.matchingFunc(entry.Name) {
+			matches = append(matches, entry)
+		}
+	}
+
+	if c.ToStdout {
+		return c.writeStdout(matches)
+	}
+
+	var dirs, links siva.Index
+	for _, entry := range matches {
+		var err error
+		switch {
+		case entry.Mode.IsDir():
+			dirs = append(dirs, entry)
+			err = c.extractDir(entry)
+		case entry.Mode&os.ModeSymlink != 0:
+			links = append(links, entry)
+		default:
+			err = c.extract(entry)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// the symbolic links are created last, so no file is written through them
+	for _, entry := range links {
+		if err := c.extractSymlink(entry); err != nil {
+			return err
 		}
+	}
 
-		if err := c.extract(entry); err != nil {
+	// extracting a file changes the modification time of its directory, so
+	// the metadata of the directories is restored at the end, deepest first
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].Name > dirs[j].Name })
+	for _, entry := range dirs {
+		if err := c.restoreMetadata(filepath.Join(c.Output.Path, entry.Name), entry); err != nil {
 			return err
 		}
 	}
@@ -104,6 +166,46 @@ func (c *CmdUnpack) do() error {
 	return nil
 }
 
+func (c *CmdUnpack) writeStdout(entries siva.Index) (err error) {
+	w := defaultOutput
+	if c.Gzip {
+		gw := gzip.NewWriter(w)
+		defer func() {
+			if errC := gw.Close(); errC != nil && err == nil {
+				err = errC
+			}
+		}()
+
+		w = gw
+	}
+
+	if c.Format == "tar" {
+		return c.writeTar(w, entries)
+	}
+
+	for _, entry := range entries {
+		src, err := c.r.Get(entry)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(w, src); err != nil {
+			return fmt.Errorf("unable to write %q : %s\n", entry.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *CmdUnpack) writeTar(w io.Writer, entries siva.Index) error {
+	tw := tar.NewWriter(w)
+	if err := siva.ToTarEntries(tw, c.r, entries); err != nil {
+		return fmt.Errorf("unable to write tar: %s\n", err)
+	}
+
+	return tw.Close()
+}
+
 func (c *CmdUnpack) extract(entry *siva.IndexEntry) error {
 	src, err := c.r.Get(entry)
 	if err != nil {
@@ -115,26 +217,73 @@ func (c *CmdUnpack) extract(entry *siva.IndexEntry) error {
 		return err
 	}
 
-	defer dst.Close()
-
 	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
 		return fmt.Errorf("unable to write %q : %s\n", entry.Name, err)
 	}
 
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("unable to write %q : %s\n", entry.Name, err)
+	}
+
+	if err := c.restoreMetadata(dst.Name(), entry); err != nil {
+		return err
+	}
+
 	c.println(entry.Name, humanize.Bytes(entry.Size))
 	return nil
 }
 
-func (c *CmdUnpack) createFile(entry *siva.IndexEntry) (*os.File, error) {
+func (c *CmdUnpack) extractDir(entry *siva.IndexEntry) error {
 	dstName := filepath.Join(c.Output.Path, entry.Name)
-
 	if err := c.checkSafePath(c.Output.Path, dstName); err != nil {
-		return nil, err
+		return err
 	}
 
-	dir := filepath.Dir(dstName)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("unable to create dir %q: %s\n", dir, err)
+	if err := os.MkdirAll(dstName, 0755); err != nil {
+		return fmt.Errorf("unable to create dir %q: %s\n", dstName, err)
+	}
+
+	c.println(entry.Name)
+	return nil
+}
+
+// extractSymlink creates a symbolic link pointing to the content of the entry.
+// Its mode and modification time are not restored.
+func (c *CmdUnpack) extractSymlink(entry *siva.IndexEntry) error {
+	src, err := c.r.Get(entry)
+	if err != nil {
+		return err
+	}
+
+	target, err := ioutil.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("unable to read %q : %s\n", entry.Name, err)
+	}
+
+	dstName, err := c.createDir(entry)
+	if err != nil {
+		return err
+	}
+
+	if c.Overwrite {
+		if err := os.Remove(dstName); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove %q: %s\n", dstName, err)
+		}
+	}
+
+	if err := os.Symlink(string(target), dstName); err != nil {
+		return fmt.Errorf("unable to create symlink %q: %s\n", dstName, err)
+	}
+
+	c.println(entry.Name, "->", string(target))
+	return nil
+}
+
+func (c *CmdUnpack) createFile(entry *siva.IndexEntry) (*os.File, error) {
+	dstName, err := c.createDir(entry)
+	if err != nil {
+		return nil, err
 	}
 
 	perms := os.FileMode(defaultPerms)
@@ -150,6 +299,47 @@ func (c *CmdUnpack) createFile(entry *siva.IndexEntry) (*os.File, error) {
 	return dst, nil
 }
 
+// createDir creates the parent directory of the given entry, returning the
+// path where the entry should be extracted.
+func (c *CmdUnpack) createDir(entry *siva.IndexEntry) (string, error) {
+	dstName := filepath.Join(c.Output.Path, entry.Name)
+
+	if err := c.checkSafePath(c.Output.Path, dstName); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(dstName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create dir %q: %s\n", dir, err)
+	}
+
+	return dstName, nil
+}
+
+// restoreMetadata sets the exact mode and the modification time of the
+// extracted file or directory, once its contents have been written. The siva format
+// doesn't record the owner of the files, so ownership is not restored.
+func (c *CmdUnpack) restoreMetadata(name string, entry *siva.IndexEntry) error {
+	if !c.IgnorePerms && !c.NoSamePerms {
+		mode := entry.Mode.Perm()
+		if c.SpecialBits {
+			mode |= entry.Mode & (os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		}
+
+		if err := os.Chmod(name, mode); err != nil {
+			return fmt.Errorf("unable to set the mode of %q: %s\n", name, err)
+		}
+	}
+
+	if !c.Touch {
+		if err := os.Chtimes(name, entry.ModTime, entry.ModTime); err != nil {
+			return fmt.Errorf("unable to set the modification time of %q: %s\n", name, err)
+		}
+	}
+
+	return nil
+}
+
 func (c *CmdUnpack) checkSafePath(base, target string) error {
 	rel, err := filepath.Rel(base, target)
 	if err != nil {
@@ -1,10 +1,18 @@
 package impl
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
 
 	. "gopkg.in/check.v1"
 )
@@ -113,3 +121,261 @@ func (s *UnpackSuite) TestZipSlip(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(os.IsNotExist(err), Equals, true)
 }
+
+func (s *UnpackSuite) TestGlob(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Glob = "**/{gopher,todo}.txt"
+
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	dir, err := ioutil.ReadDir(cmd.Output.Path)
+	c.Assert(err, IsNil)
+	c.Assert(dir, HasLen, 2)
+	c.Assert(dir[0].Name(), Equals, "gopher.txt")
+	c.Assert(dir[1].Name(), Equals, "todo.txt")
+}
+
+func (s *UnpackSuite) TestToStdout(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "basic.siva")
+	cmd.Glob = "{gopher,todo}.txt"
+	cmd.ToStdout = true
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	c.Assert(output, Equals, files[0].Body+files[2].Body)
+}
+
+func (s *UnpackSuite) TestToStdoutTarGzip(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+	cmd.ToStdout = true
+	cmd.Format = "tar"
+	cmd.Gzip = true
+
+	output := captureOutput(func() {
+		err := cmd.Execute(nil)
+		c.Assert(err, IsNil)
+	})
+
+	gr, err := gzip.NewReader(strings.NewReader(output))
+	c.Assert(err, IsNil)
+
+	tr := tar.NewReader(gr)
+	for _, f := range files {
+		th, err := tr.Next()
+		c.Assert(err, IsNil)
+		c.Assert(th.Name, Equals, f.Name)
+
+		content, err := ioutil.ReadAll(tr)
+		c.Assert(err, IsNil)
+		c.Assert(string(content), Equals, f.Body)
+	}
+
+	_, err = tr.Next()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *UnpackSuite) TestFormatWithoutStdout(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Format = "tar"
+
+	c.Assert(cmd.Execute(nil), NotNil)
+}
+
+func (s *UnpackSuite) TestModTime(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	i, err := cmd.r.Index()
+	c.Assert(err, IsNil)
+
+	for _, e := range i.Filter() {
+		fi, err := os.Stat(filepath.Join(cmd.Output.Path, e.Name))
+		c.Assert(err, IsNil)
+		c.Assert(fi.ModTime().Equal(e.ModTime), Equals, true)
+	}
+}
+
+func (s *UnpackSuite) TestTouch(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+	cmd.Touch = true
+
+	start := time.Now().Add(-time.Minute)
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	dir, err := ioutil.ReadDir(cmd.Output.Path)
+	c.Assert(err, IsNil)
+	c.Assert(dir, HasLen, 3)
+
+	for _, f := range dir {
+		c.Assert(f.ModTime().After(start), Equals, true)
+	}
+}
+
+func (s *UnpackSuite) TestExactPerms(c *C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("permissions are not supported on windows")
+	}
+
+	path := filepath.Join(s.folder, "modes.siva")
+	writeModes(c, path, map[string]os.FileMode{
+		"all": 0777,
+		"uid": 0755 | os.ModeSetuid,
+	})
+
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = path
+
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	assertMode(c, filepath.Join(cmd.Output.Path, "all"), 0777)
+	assertMode(c, filepath.Join(cmd.Output.Path, "uid"), 0755)
+
+	cmd = &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "special")
+	cmd.Args.File = path
+	cmd.SpecialBits = true
+
+	err = cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	assertMode(c, filepath.Join(cmd.Output.Path, "uid"), 0755|os.ModeSetuid)
+}
+
+func (s *UnpackSuite) TestNoSamePerms(c *C) {
+	path := filepath.Join(s.folder, "modes.siva")
+	writeModes(c, path, map[string]os.FileMode{"all": 0777})
+
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = path
+	cmd.NoSamePerms = true
+
+	err := cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	umasked := filepath.Join(s.folder, "umasked")
+	f, err := os.OpenFile(umasked, os.O_CREATE|os.O_WRONLY, 0777)
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	expected, err := os.Stat(umasked)
+	c.Assert(err, IsNil)
+
+	assertMode(c, filepath.Join(cmd.Output.Path, "all"), expected.Mode())
+}
+
+func (s *UnpackSuite) TestSpecialBitsWithoutPerms(c *C) {
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = filepath.Join("..", "..", "..", "fixtures", "perms.siva")
+	cmd.SpecialBits = true
+	cmd.NoSamePerms = true
+
+	err := cmd.Execute(nil)
+	c.Assert(err, NotNil)
+}
+
+func writeModes(c *C, path string, modes map[string]os.FileMode) {
+	f, err := os.Create(path)
+	c.Assert(err, IsNil)
+
+	w := siva.NewWriter(f)
+	for name, mode := range modes {
+		err := w.WriteHeader(&siva.Header{
+			Name:    name,
+			Mode:    mode,
+			ModTime: time.Now(),
+		})
+		c.Assert(err, IsNil)
+
+		_, err = w.Write([]byte(name))
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	c.Assert(f.Close(), IsNil)
+}
+
+func assertMode(c *C, path string, expected os.FileMode) {
+	fi, err := os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Assert(fi.Mode(), Equals, expected)
+}
+
+func (s *UnpackSuite) TestDirsAndSymlinks(c *C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("symbolic links require privileges on windows")
+	}
+
+	modTime := time.Date(2018, 5, 4, 3, 2, 1, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, h := range []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "d/", Typeflag: tar.TypeDir, Mode: 0750},
+		{Name: "d/e/", Typeflag: tar.TypeDir, Mode: 0700},
+		{Name: "d/e/f", Typeflag: tar.TypeReg, Mode: 0644, Size: 3},
+		{Name: "d/l", Typeflag: tar.TypeSymlink, Linkname: "e/f", Mode: 0777},
+	} {
+		h.ModTime = modTime
+		c.Assert(tw.WriteHeader(h), IsNil)
+		if h.Size != 0 {
+			_, err := tw.Write([]byte("foo"))
+			c.Assert(err, IsNil)
+		}
+	}
+
+	c.Assert(tw.Close(), IsNil)
+
+	path := filepath.Join(s.folder, "dirs.siva")
+	f, err := os.Create(path)
+	c.Assert(err, IsNil)
+	w := siva.NewWriter(f)
+	c.Assert(siva.FromTar(w, tar.NewReader(buf)), IsNil)
+	c.Assert(w.Close(), IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	cmd := &CmdUnpack{}
+	cmd.Output.Path = filepath.Join(s.folder, "files")
+	cmd.Args.File = path
+
+	err = cmd.Execute(nil)
+	c.Assert(err, IsNil)
+
+	for name, mode := range map[string]os.FileMode{
+		"d":   os.ModeDir | 0750,
+		"d/e": os.ModeDir | 0700,
+	} {
+		fi, err := os.Stat(filepath.Join(cmd.Output.Path, name))
+		c.Assert(err, IsNil)
+		c.Assert(fi.Mode(), Equals, mode)
+		c.Assert(fi.ModTime().Equal(modTime), Equals, true)
+	}
+
+	target, err := os.Readlink(filepath.Join(cmd.Output.Path, "d", "l"))
+	c.Assert(err, IsNil)
+	c.Assert(target, Equals, "e/f")
+
+	data, err := ioutil.ReadFile(filepath.Join(cmd.Output.Path, "d", "l"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "foo")
+}
@@ -0,0 +1,80 @@
+package siva
+
+import "sort"
+
+// ChangeType is the kind of difference between two indexes reported by Diff.
+type ChangeType int
+
+const (
+	// ChangeAdded is reported for names only in the second index.
+	ChangeAdded ChangeType = iota
+	// ChangeRemoved is reported for names only in the first index.
+	ChangeRemoved
+	// ChangeModified is reported for names whose content differs, by size or
+	// CRC32.
+	ChangeModified
+	// ChangeMode is reported for names with the same content and different
+	// mode.
+	ChangeMode
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	case ChangeMode:
+		return "mode changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a difference between two indexes, From is the entry in the first
+// index and To the entry in the second one, nil for added and removed names
+// respectively.
+type Change struct {
+	Type ChangeType
+	Name string
+	From *IndexEntry
+	To   *IndexEntry
+}
+
+// Diff compares the filtered versions of the indexes a and b and returns the
+// changes needed to go from a to b, sorted by name. Entries with the same
+// name, size, CRC32 and mode are considered equal, their modification time
+// is ignored.
+func Diff(a, b Index) []Change {
+	from := make(map[string]*IndexEntry)
+	for _, e := range a.Filter() {
+		from[e.Name] = e
+	}
+
+	var changes []Change
+	for _, e := range b.Filter() {
+		prev, ok := from[e.Name]
+		delete(from, e.Name)
+
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: ChangeAdded, Name: e.Name, To: e})
+		case prev.Size != e.Size || prev.CRC32 != e.CRC32:
+			changes = append(changes, Change{Type: ChangeModified, Name: e.Name, From: prev, To: e})
+		case prev.Mode != e.Mode:
+			changes = append(changes, Change{Type: ChangeMode, Name: e.Name, From: prev, To: e})
+		}
+	}
+
+	for name, e := range from {
+		changes = append(changes, Change{Type: ChangeRemoved, Name: name, From: e})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
@@ -0,0 +1,73 @@
+package siva_test
+
+import (
+	"bytes"
+	"os"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func (s *DiffSuite) TestDiff(c *C) {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf).(siva.Committer)
+	writeHeader(c, w, &siva.Header{Name: "same", Mode: 0644}, "same")
+	writeHeader(c, w, &siva.Header{Name: "modified", Mode: 0644}, "foo")
+	writeHeader(c, w, &siva.Header{Name: "resized", Mode: 0644}, "foo")
+	writeHeader(c, w, &siva.Header{Name: "mode", Mode: 0644}, "mode")
+	writeHeader(c, w, &siva.Header{Name: "removed", Mode: 0644}, "removed")
+	writeHeader(c, w, &siva.Header{Name: "deleted", Mode: 0644}, "deleted")
+	c.Assert(w.Commit(), IsNil)
+
+	a, err := siva.NewReader(bytes.NewReader(buf.Bytes())).Index()
+	c.Assert(err, IsNil)
+
+	writeHeader(c, w, &siva.Header{Name: "same", Mode: 0644}, "same")
+	writeHeader(c, w, &siva.Header{Name: "modified", Mode: 0755}, "bar")
+	writeHeader(c, w, &siva.Header{Name: "resized", Mode: 0644}, "fooo")
+	writeHeader(c, w, &siva.Header{Name: "mode", Mode: 0755}, "mode")
+	writeHeader(c, w, &siva.Header{Name: "added", Mode: 0644}, "added")
+	writeHeader(c, w, &siva.Header{Name: "deleted", Flags: siva.FlagDeleted}, "")
+	c.Assert(w.Close(), IsNil)
+
+	b, err := siva.NewReader(bytes.NewReader(buf.Bytes())).Index()
+	c.Assert(err, IsNil)
+
+	// the first block is still there, but "removed" is not in the new one
+	b = b.Query(siva.Predicate(func(e *siva.IndexEntry) bool { return e.Name != "removed" }))
+
+	changes := siva.Diff(a, b)
+	c.Assert(changes, HasLen, 6)
+
+	expected := []struct {
+		name string
+		typ  siva.ChangeType
+	}{
+		{"added", siva.ChangeAdded},
+		{"deleted", siva.ChangeRemoved},
+		{"mode", siva.ChangeMode},
+		{"modified", siva.ChangeModified},
+		{"removed", siva.ChangeRemoved},
+		{"resized", siva.ChangeModified},
+	}
+
+	for n, e := range expected {
+		c.Assert(changes[n].Name, Equals, e.name)
+		c.Assert(changes[n].Type, Equals, e.typ, Commentf("name %s", e.name))
+	}
+
+	c.Assert(changes[0].From, IsNil)
+	c.Assert(changes[0].To.Name, Equals, "added")
+	c.Assert(changes[1].From.Name, Equals, "deleted")
+	c.Assert(changes[1].To, IsNil)
+	c.Assert(changes[2].From.Mode, Equals, os.FileMode(0644))
+	c.Assert(changes[2].To.Mode, Equals, os.FileMode(0755))
+
+	c.Assert(siva.Diff(b, b), HasLen, 0)
+	c.Assert(siva.ChangeMode.String(), Equals, "mode changed")
+}
@@ -0,0 +1,194 @@
+package siva
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SkipDir is used as a return value from a WalkFunc to indicate that the
+// directory named in the call is to be skipped, it is the same value as
+// filepath.SkipDir.
+var SkipDir = filepath.SkipDir
+
+// DirEntry is a file or a directory found by ReadDir or Walk. Directories are
+// usually not stored in siva files, they are derived from the names of the
+// files, in that case Entry is nil.
+type DirEntry struct {
+	// Name is the base name of the file or directory.
+	Name string
+	// Entry is the IndexEntry of the file or directory, if any.
+	Entry *IndexEntry
+}
+
+// IsDir reports whether the entry describes a directory.
+func (d *DirEntry) IsDir() bool {
+	return d.Entry == nil || d.Entry.Mode.IsDir()
+}
+
+// WalkFunc is the type of the function called by Walk to visit each file or
+// directory, with the same semantics as filepath.WalkDirFunc. The only error
+// reported to it is the one caused by a non existent root, in that case d is
+// nil.
+type WalkFunc func(path string, d *DirEntry, err error) error
+
+// ReadDir returns the files and directories directly contained in dir,
+// sorted by name. The root of the archive is "" or ".". The index is used as
+// is, so Filter should be called first on indexes with several entries for
+// the same name, otherwise a DirEntry is returned for each of them. Unless the
+// index is already sorted it is copied and sorted on every call, OrderedIndex
+// should be used to read several directories.
+func (i Index) ReadDir(dir string) ([]*DirEntry, error) {
+	return i.ordered().ReadDir(dir)
+}
+
+// Walk walks the file tree rooted at root, calling fn for each file or
+// directory in the tree, including root. See OrderedIndex.Walk. As with
+// ReadDir, Filter should be called first on indexes with several entries for
+// the same name.
+func (i Index) Walk(root string, fn WalkFunc) error {
+	return i.ordered().Walk(root, fn)
+}
+
+func (i Index) ordered() OrderedIndex {
+	if sort.IsSorted(OrderedIndex(i)) {
+		return OrderedIndex(i)
+	}
+
+	o := make(OrderedIndex, len(i))
+	copy(o, i)
+	sort.Stable(o)
+
+	return o
+}
+
+// ReadDir returns the files and directories directly contained in dir,
+// sorted by name. The root of the archive is "" or ".".
+func (o OrderedIndex) ReadDir(dir string) ([]*DirEntry, error) {
+	dir = ToSafePath(dir)
+	entries := o.readDir(dir)
+	if len(entries) == 0 && dir != "" {
+		return nil, &os.PathError{Op: "readdir", Path: dir, Err: os.ErrNotExist}
+	}
+
+	return entries, nil
+}
+
+func (o OrderedIndex) readDir(dir string) []*DirEntry {
+	prefix := dirPrefix(dir)
+
+	var entries []*DirEntry
+	dirs := make(map[string]*DirEntry)
+	for pos := o.Pos(prefix); pos < len(o); {
+		e := o[pos]
+		if !strings.HasPrefix(e.Name, prefix) {
+			break
+		}
+
+		name := e.Name[len(prefix):]
+		slash := strings.IndexByte(name, '/')
+		if slash == -1 {
+			pos++
+			if d, ok := dirs[name]; ok && e.Mode.IsDir() {
+				d.Entry = e
+				continue
+			}
+
+			d := &DirEntry{Name: name, Entry: e}
+			if e.Mode.IsDir() {
+				dirs[name] = d
+			}
+
+			entries = append(entries, d)
+			continue
+		}
+
+		// all the names under a directory are contiguous, so we can jump to
+		// the first name after them, '0' is the next character after '/'.
+		name = name[:slash]
+		pos = o.Pos(prefix + name + "0")
+		if _, ok := dirs[name]; ok {
+			continue
+		}
+
+		d := &DirEntry{Name: name}
+		dirs[name] = d
+		entries = append(entries, d)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// Walk walks the file tree rooted at root, calling fn for each file or
+// directory in the tree, including root. The files are walked in lexical
+// order and the directories are derived from the names of the files. If fn
+// returns SkipDir for a directory its contents are skipped, for a file the
+// remaining files in the containing directory are skipped.
+func (o OrderedIndex) Walk(root string, fn WalkFunc) error {
+	root = ToSafePath(root)
+
+	var d *DirEntry
+	if root == "" {
+		root = "."
+		d = &DirEntry{Name: root}
+	} else if e := o.Find(root); e != nil {
+		d = &DirEntry{Name: path.Base(root), Entry: e}
+	} else if pos := o.Pos(dirPrefix(root)); pos < len(o) &&
+		strings.HasPrefix(o[pos].Name, dirPrefix(root)) {
+		d = &DirEntry{Name: path.Base(root)}
+	}
+
+	var err error
+	if d == nil {
+		err = fn(root, nil, &os.PathError{Op: "walk", Path: root, Err: os.ErrNotExist})
+	} else {
+		err = o.walk(root, d, fn)
+	}
+
+	if err == SkipDir {
+		return nil
+	}
+
+	return err
+}
+
+func (o OrderedIndex) walk(name string, d *DirEntry, fn WalkFunc) error {
+	if err := fn(name, d, nil); err != nil || !d.IsDir() {
+		if err == SkipDir && d.IsDir() {
+			err = nil
+		}
+
+		return err
+	}
+
+	dir := name
+	if dir == "." {
+		dir = ""
+	}
+
+	for _, child := range o.readDir(dir) {
+		if err := o.walk(path.Join(dir, child.Name), child, fn); err != nil {
+			if err == SkipDir {
+				break
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dirPrefix(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	return dir + "/"
+}
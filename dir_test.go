@@ -0,0 +1,173 @@
+package siva
+
+import (
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+type DirSuite struct{}
+
+var _ = Suite(&DirSuite{})
+
+var treeIndex = Index{
+	{Header: Header{Name: "a/b/c.txt"}},
+	{Header: Header{Name: "a/b.txt"}},
+	{Header: Header{Name: "a-b.txt"}},
+	{Header: Header{Name: "a/b/d/e.txt"}},
+	{Header: Header{Name: "z.txt"}},
+	{Header: Header{Name: "a/c", Mode: os.ModeDir | 0755}},
+	{Header: Header{Name: "a/c/f.txt"}},
+}
+
+func (s *DirSuite) TestReadDir(c *C) {
+	s.testReadDir(c, "", []string{"a/", "a-b.txt", "z.txt"})
+	s.testReadDir(c, ".", []string{"a/", "a-b.txt", "z.txt"})
+	s.testReadDir(c, "a", []string{"b/", "b.txt", "c/"})
+	s.testReadDir(c, "a/", []string{"b/", "b.txt", "c/"})
+	s.testReadDir(c, "/a/b", []string{"c.txt", "d/"})
+	s.testReadDir(c, "a/c", []string{"f.txt"})
+}
+
+func (s *DirSuite) testReadDir(c *C, dir string, expected []string) {
+	entries, err := treeIndex.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(dirEntryNames(entries), DeepEquals, expected)
+
+	o := treeIndex.ordered()
+	entries, err = o.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(dirEntryNames(entries), DeepEquals, expected)
+}
+
+func (s *DirSuite) TestReadDirExplicitDir(c *C) {
+	entries, err := treeIndex.ReadDir("a")
+	c.Assert(err, IsNil)
+	c.Assert(entries[2].Entry, NotNil)
+	c.Assert(entries[2].Entry.Name, Equals, "a/c")
+	c.Assert(entries[0].Entry, IsNil)
+}
+
+func (s *DirSuite) TestOrdered(c *C) {
+	o := treeIndex.ordered()
+	c.Assert(&o[0], Not(Equals), &treeIndex[0])
+	c.Assert(treeIndex[0].Name, Equals, "a/b/c.txt")
+
+	sorted := Index(o)
+	c.Assert(&sorted.ordered()[0], Equals, &o[0])
+}
+
+func (s *DirSuite) TestReadDirDuplicated(c *C) {
+	i := Index{
+		{Header: Header{Name: "foo"}},
+		{Header: Header{Name: "foo"}},
+	}
+
+	entries, err := i.ReadDir("")
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+
+	entries, err = i.Filter().ReadDir("")
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+}
+
+func (s *DirSuite) TestReadDirNotExist(c *C) {
+	_, err := treeIndex.ReadDir("foo")
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	_, err = treeIndex.ReadDir("z.txt")
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	entries, err := Index{}.ReadDir("")
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 0)
+}
+
+func (s *DirSuite) TestWalk(c *C) {
+	c.Assert(s.walk(c, "", nil), DeepEquals, []string{
+		"./", "a/", "a/b/", "a/b/c.txt", "a/b/d/", "a/b/d/e.txt",
+		"a/b.txt", "a/c/", "a/c/f.txt", "a-b.txt", "z.txt",
+	})
+
+	c.Assert(s.walk(c, "a/b", nil), DeepEquals, []string{
+		"a/b/", "a/b/c.txt", "a/b/d/", "a/b/d/e.txt",
+	})
+
+	c.Assert(s.walk(c, "z.txt", nil), DeepEquals, []string{"z.txt"})
+}
+
+func (s *DirSuite) TestWalkSkipDir(c *C) {
+	names := s.walk(c, "", func(path string, d *DirEntry) error {
+		if path == "a/b" {
+			return SkipDir
+		}
+
+		return nil
+	})
+
+	c.Assert(names, DeepEquals, []string{
+		"./", "a/", "a/b/", "a/b.txt", "a/c/", "a/c/f.txt", "a-b.txt", "z.txt",
+	})
+
+	names = s.walk(c, "", func(path string, d *DirEntry) error {
+		if path == "a/b/c.txt" {
+			return SkipDir
+		}
+
+		return nil
+	})
+
+	c.Assert(names, DeepEquals, []string{
+		"./", "a/", "a/b/", "a/b/c.txt", "a/b.txt", "a/c/", "a/c/f.txt",
+		"a-b.txt", "z.txt",
+	})
+}
+
+func (s *DirSuite) TestWalkNotExist(c *C) {
+	var called bool
+	err := treeIndex.Walk("foo", func(path string, d *DirEntry, err error) error {
+		called = true
+		c.Assert(d, IsNil)
+		c.Assert(os.IsNotExist(err), Equals, true)
+		return err
+	})
+
+	c.Assert(called, Equals, true)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *DirSuite) walk(c *C, root string, fn func(string, *DirEntry) error) []string {
+	var names []string
+	err := treeIndex.Walk(root, func(path string, d *DirEntry, err error) error {
+		c.Assert(err, IsNil)
+		name := path
+		if d.IsDir() {
+			name += "/"
+		}
+
+		names = append(names, name)
+		if fn != nil {
+			return fn(path, d)
+		}
+
+		return nil
+	})
+
+	c.Assert(err, IsNil)
+	return names
+}
+
+func dirEntryNames(entries []*DirEntry) []string {
+	names := []string{}
+	for _, e := range entries {
+		name := e.Name
+		if e.IsDir() {
+			name += "/"
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,182 @@
+package siva
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	ErrLockTimeout  = errors.New("timeout acquiring the file lock")
+	ErrReadOnlyFile = errors.New("file is opened read-only")
+
+	errLocked = errors.New("file is locked")
+)
+
+// lockPollInterval is the time waited between attempts to acquire a lock when
+// a timeout is given.
+const lockPollInterval = 10 * time.Millisecond
+
+// File is a siva file opened with OpenFile, it holds an advisory lock on the
+// file until it is closed. Files opened for writing hold an exclusive lock,
+// read-only files hold a shared one, so readers see a stable view of the
+// archive while no writer can append to it.
+type File struct {
+	*ReadWriter
+	f         *os.File
+	exclusive bool
+}
+
+// OpenFile opens the named siva file with the given flags (os.O_RDONLY,
+// os.O_RDWR, os.O_CREATE, ...), waiting as long as needed for the lock. See
+// OpenFileTimeout.
+func OpenFile(path string, flag int) (*File, error) {
+	return OpenFileTimeout(path, flag, 0)
+}
+
+// OpenFileTimeout opens the named siva file with the given flags, waiting up
+// to timeout for the lock, a zero timeout waits forever. ErrLockTimeout is
+// returned if the lock could not be acquired in time.
+//
+// If flag contains os.O_WRONLY or os.O_RDWR the file is opened for reading and
+// writing and the lock is exclusive, otherwise the lock is shared. The
+// os.O_TRUNC flag is honored once the lock is held, so a concurrent writer is
+// never truncated.
+func OpenFileTimeout(path string, flag int, timeout time.Duration) (*File, error) {
+	exclusive := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	truncate := flag&os.O_TRUNC != 0
+
+	flag &^= os.O_TRUNC
+	if exclusive {
+		flag = flag&^os.O_WRONLY | os.O_RDWR
+	}
+
+	f, err := os.OpenFile(path, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f, exclusive, timeout); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if truncate {
+		if err := f.Truncate(0); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+
+	rw, err := NewReaderWriter(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &File{ReadWriter: rw, f: f, exclusive: exclusive}, nil
+}
+
+// Name returns the name of the file as presented to OpenFile.
+func (f *File) Name() string {
+	return f.f.Name()
+}
+
+// Stat returns the os.FileInfo describing the underlying file.
+func (f *File) Stat() (os.FileInfo, error) {
+	return f.f.Stat()
+}
+
+// ReadAt reads from the underlying file, see os.File.ReadAt.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	return f.f.ReadAt(p, off)
+}
+
+// WriterAt returns a WriterAt appending a new block to the file, the file
+// must have been opened for writing without os.O_APPEND. The entries written
+// with the WriterAt are visible through f once it is closed, f must not be
+// written meanwhile. If closing the WriterAt fails, or it is aborted, the file
+// is truncated back to its current size.
+func (f *File) WriterAt() (*WriterAt, error) {
+	if !f.exclusive {
+		return nil, ErrReadOnlyFile
+	}
+
+	fi, err := f.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := fi.Size()
+	w := NewWriterAt(f.f, size)
+	w.onClose = func(rollback bool) error {
+		if rollback {
+			if err := f.f.Truncate(size); err != nil {
+				return fmt.Errorf("restoring the previous size failed: %s", err)
+			}
+
+			return nil
+		}
+
+		rw, err := NewReaderWriter(f.f)
+		if err != nil {
+			return err
+		}
+
+		f.ReadWriter = rw
+		return nil
+	}
+
+	return w, nil
+}
+
+// Close writes the index of any pending entries, releases the lock and
+// closes the underlying file.
+func (f *File) Close() error {
+	err := f.ReadWriter.Close()
+	if err == nil && f.exclusive {
+		err = f.f.Sync()
+	}
+
+	if errU := unlock(f.f); errU != nil && err == nil {
+		err = errU
+	}
+
+	if errC := f.f.Close(); errC != nil && err == nil {
+		err = errC
+	}
+
+	return err
+}
+
+// lockFile acquires a shared or exclusive lock on f, if timeout is not zero
+// the lock is retried until it expires.
+func lockFile(f *os.File, exclusive bool, timeout time.Duration) error {
+	if timeout == 0 {
+		return lock(f, exclusive, true)
+	}
+
+	return retryLock(func() error {
+		return lock(f, exclusive, false)
+	}, timeout)
+}
+
+// retryLock calls tryLock until it acquires the lock, returning any error
+// other than errLocked, or until the timeout expires. A zero timeout retries
+// forever.
+func retryLock(tryLock func() error, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock()
+		if err != errLocked {
+			return err
+		}
+
+		if timeout != 0 && time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
@@ -0,0 +1,141 @@
+package siva_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type FileSuite struct {
+	tmpDir string
+}
+
+var _ = Suite(&FileSuite{})
+
+func (s *FileSuite) SetUpTest(c *C) {
+	s.tmpDir = c.MkDir()
+}
+
+func (s *FileSuite) TestWriteAndRead(c *C) {
+	path := filepath.Join(s.tmpDir, "foo.siva")
+
+	f, err := siva.OpenFile(path, os.O_WRONLY|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "foo", "bar")
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_WRONLY|os.O_APPEND)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "qux", "baz")
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_RDONLY)
+	c.Assert(err, IsNil)
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 2)
+
+	e := i.Find("qux")
+	c.Assert(e, NotNil)
+
+	sr, err := f.Get(e)
+	c.Assert(err, IsNil)
+	content, err := ioutil.ReadAll(sr)
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "baz")
+
+	c.Assert(f.Close(), IsNil)
+}
+
+func (s *FileSuite) TestTruncate(c *C) {
+	path := filepath.Join(s.tmpDir, "foo.siva")
+
+	f, err := siva.OpenFile(path, os.O_WRONLY|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "foo", "bar")
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_WRONLY|os.O_TRUNC)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "qux", "baz")
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_RDONLY)
+	c.Assert(err, IsNil)
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 1)
+	c.Assert(i[0].Name, Equals, "qux")
+
+	c.Assert(f.Close(), IsNil)
+}
+
+func (s *FileSuite) TestExclusiveLock(c *C) {
+	path := filepath.Join(s.tmpDir, "foo.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+
+	_, err = siva.OpenFileTimeout(path, os.O_RDWR, 50*time.Millisecond)
+	c.Assert(err, Equals, siva.ErrLockTimeout)
+
+	_, err = siva.OpenFileTimeout(path, os.O_RDONLY, 50*time.Millisecond)
+	c.Assert(err, Equals, siva.ErrLockTimeout)
+
+	done := make(chan error)
+	go func() {
+		f, err := siva.OpenFileTimeout(path, os.O_RDWR, 5*time.Second)
+		if err == nil {
+			err = f.Close()
+		}
+
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	writeEntry(c, w, "foo", "bar")
+	c.Assert(w.Close(), IsNil)
+	c.Assert(<-done, IsNil)
+}
+
+func (s *FileSuite) TestSharedLock(c *C) {
+	path := filepath.Join(s.tmpDir, "foo.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "bar")
+	c.Assert(w.Close(), IsNil)
+
+	r1, err := siva.OpenFileTimeout(path, os.O_RDONLY, 50*time.Millisecond)
+	c.Assert(err, IsNil)
+	r2, err := siva.OpenFileTimeout(path, os.O_RDONLY, 50*time.Millisecond)
+	c.Assert(err, IsNil)
+
+	_, err = siva.OpenFileTimeout(path, os.O_RDWR, 50*time.Millisecond)
+	c.Assert(err, Equals, siva.ErrLockTimeout)
+
+	c.Assert(r1.Close(), IsNil)
+	c.Assert(r2.Close(), IsNil)
+
+	w, err = siva.OpenFileTimeout(path, os.O_RDWR, 50*time.Millisecond)
+	c.Assert(err, IsNil)
+	c.Assert(w.Close(), IsNil)
+}
+
+func writeEntry(c *C, w siva.Writer, name, content string) {
+	writeHeader(c, w, &siva.Header{Name: name}, content)
+}
+
+func writeHeader(c *C, w siva.Writer, h *siva.Header, content string) {
+	c.Assert(w.WriteHeader(h), IsNil)
+	_, err := w.Write([]byte(content))
+	c.Assert(err, IsNil)
+	c.Assert(w.Flush(), IsNil)
+}
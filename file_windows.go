@@ -0,0 +1,64 @@
+//go:build windows
+// +build windows
+
+package siva
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+
+	errorLockViolation syscall.Errno = 33
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+func lock(f *os.File, exclusive, wait bool) error {
+	var flags uint32
+	if exclusive {
+		flags |= lockfileExclusiveLock
+	}
+
+	if !wait {
+		flags |= lockfileFailImmediately
+	}
+
+	ol := new(syscall.Overlapped)
+	r, _, err := procLockFileEx.Call(
+		f.Fd(), uintptr(flags), 0, ^uintptr(0), ^uintptr(0),
+		uintptr(unsafe.Pointer(ol)),
+	)
+
+	if r != 0 {
+		return nil
+	}
+
+	if err == errorLockViolation || err == syscall.ERROR_IO_PENDING {
+		return errLocked
+	}
+
+	return &os.PathError{Op: "LockFileEx", Path: f.Name(), Err: err}
+}
+
+func unlock(f *os.File) error {
+	ol := new(syscall.Overlapped)
+	r, _, err := procUnlockFileEx.Call(
+		f.Fd(), 0, ^uintptr(0), ^uintptr(0),
+		uintptr(unsafe.Pointer(ol)),
+	)
+
+	if r == 0 {
+		return &os.PathError{Op: "UnlockFileEx", Path: f.Name(), Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package siva
+
+import (
+	"path"
+	"strings"
+)
+
+// glob is a compiled glob pattern, with its brace alternatives expanded and
+// split in path segments.
+type glob [][]string
+
+// compileGlob compiles a pattern with the syntax of path.Match extended with
+// brace alternation, {a,b}, and `**` segments matching zero or more path
+// segments.
+func compileGlob(pattern string) (glob, error) {
+	alts, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	g := make(glob, len(alts))
+	for i, alt := range alts {
+		segments := strings.Split(alt, "/")
+		for _, s := range segments {
+			if _, err := path.Match(s, ""); err != nil {
+				return nil, err
+			}
+		}
+
+		g[i] = segments
+	}
+
+	return g, nil
+}
+
+// Match reports whether name matches any of the alternatives of the pattern.
+func (g glob) Match(name string) bool {
+	segments := strings.Split(name, "/")
+	for _, alt := range g {
+		if matchSegments(alt, segments) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		// the pattern was already validated, so no error is possible.
+		if m, _ := path.Match(pattern[0], name[0]); !m {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// expandBraces returns all the alternatives of a pattern with brace
+// alternation, {a,b}, nesting is allowed. Braces inside character classes,
+// unmatched closing braces and groups without commas are kept as literals.
+func expandBraces(pattern string) ([]string, error) {
+	open := -1
+	depth := 0
+	var commas []int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			i = classEnd(pattern, i)
+		case '{':
+			if depth == 0 {
+				open = i
+			}
+
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			if len(commas) == 0 {
+				i, open = open, -1
+				continue
+			}
+
+			return expandAlternatives(pattern, open, i, commas)
+		}
+	}
+
+	if depth != 0 {
+		return nil, path.ErrBadPattern
+	}
+
+	return []string{pattern}, nil
+}
+
+// classEnd returns the position of the end of the character class starting
+// at i, or the end of the pattern if it isn't terminated.
+func classEnd(pattern string, i int) int {
+	for i++; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+
+	return len(pattern)
+}
+
+func expandAlternatives(pattern string, open, close int, commas []int) ([]string, error) {
+	prefix, suffix := pattern[:open], pattern[close+1:]
+
+	var result []string
+	start := open + 1
+	for _, end := range append(commas, close) {
+		alts, err := expandBraces(prefix + pattern[start:end] + suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, alts...)
+		start = end + 1
+	}
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package siva
+
+import (
+	"path"
+
+	. "gopkg.in/check.v1"
+)
+
+type GlobSuite struct{}
+
+var _ = Suite(&GlobSuite{})
+
+func (s *GlobSuite) TestMatch(c *C) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*", "foo", true},
+		{"*", "foo/bar", false},
+		{"**", "foo/bar", true},
+		{"**", "foo", true},
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/foo/bar/main.go", true},
+		{"src/**/*.go", "src/foo/bar/main.c", false},
+		{"src/**/*.go", "main.go", false},
+		{"src/**", "src", true},
+		{"src/**/**/*.go", "src/a/b.go", true},
+		{"**/foo", "foo", true},
+		{"**/foo", "a/b/foo", true},
+		{"**/foo", "a/b/foobar", false},
+		{"*.{go,md}", "README.md", true},
+		{"*.{go,md}", "main.go", true},
+		{"*.{go,md}", "main.c", false},
+		{"{a,b/{c,d}}/e", "b/d/e", true},
+		{"{a,b/{c,d}}/e", "a/e", true},
+		{"{a,b/{c,d}}/e", "b/e", false},
+		{"a**b", "axxb", true},
+		{"a**b", "ax/xb", false},
+		{"[a-c]?/*", "bx/y", true},
+		{"a}", "a}", true},
+		{"x[{]y", "x{y", true},
+		{"x[{,}]y", "x,y", true},
+		{"{a}", "{a}", true},
+		{"{a}", "a", false},
+		{"{{a,b}}", "{b}", true},
+		{"{a}.{go,md}", "{a}.md", true},
+		{"{a,b}}", "b}", true},
+	}
+
+	for _, t := range tests {
+		g, err := compileGlob(t.pattern)
+		c.Assert(err, IsNil)
+		c.Assert(g.Match(t.name), Equals, t.match,
+			Commentf("pattern %q, name %q", t.pattern, t.name))
+	}
+}
+
+func (s *GlobSuite) TestBadPattern(c *C) {
+	for _, p := range []string{"{a,b", "{{a,b}", "[a-", "**/[", "{a,[}"} {
+		_, err := compileGlob(p)
+		c.Assert(err, Equals, path.ErrBadPattern, Commentf("pattern %q", p))
+	}
+}
@@ -1,9 +1,15 @@
 module gopkg.in/src-d/go-siva.v1
 
+go 1.21
+
 require (
 	github.com/dustin/go-humanize v1.0.0
 	github.com/google/go-cmp v0.3.0
 	github.com/jessevdk/go-flags v1.4.0
-	github.com/kr/pretty v0.1.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127
 )
+
+require (
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/kr/text v0.1.0 // indirect
+)
@@ -0,0 +1,122 @@
+package siva
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// HTTPHandler returns a http.Handler serving the files of the filtered index
+// of r, with the request path as the name of the file. The contents are
+// served with http.ServeContent so range and conditional requests are
+// supported, the Last-Modified header is the ModTime of the entry and the
+// ETag its CRC32. Requests to directories are answered with a listing of
+// their files.
+func HTTPHandler(r Reader) http.Handler {
+	return &httpHandler{r: r}
+}
+
+type httpHandler struct {
+	mutex   sync.Mutex
+	r       Reader
+	index   Index
+	ordered OrderedIndex
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	i, err := h.orderedIndex()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := ToSafePath(req.URL.Path)
+	if e := i.Find(name); e != nil && !e.Mode.IsDir() {
+		h.serveFile(w, req, e)
+		return
+	}
+
+	h.serveDir(w, req, i, name)
+}
+
+// orderedIndex returns the index of the reader sorted by name, it's only
+// sorted again when the reader returns a different index.
+func (h *httpHandler) orderedIndex() (OrderedIndex, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	i, err := h.r.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(i) != len(h.index) || (len(i) != 0 && &i[0] != &h.index[0]) {
+		h.index = i
+		h.ordered = i.ordered()
+	}
+
+	return h.ordered, nil
+}
+
+func (h *httpHandler) serveFile(w http.ResponseWriter, req *http.Request, e *IndexEntry) {
+	if strings.HasSuffix(req.URL.Path, "/") {
+		localRedirect(w, req, "../"+path.Base(e.Name))
+		return
+	}
+
+	content, err := h.r.Get(e)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf(`"%08x"`, e.CRC32))
+	http.ServeContent(w, req, e.Name, e.ModTime, content)
+}
+
+func (h *httpHandler) serveDir(w http.ResponseWriter, req *http.Request, i OrderedIndex, name string) {
+	entries, err := i.ReadDir(name)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	if !strings.HasSuffix(req.URL.Path, "/") {
+		localRedirect(w, req, path.Base(req.URL.Path)+"/")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<pre>\n")
+	for _, d := range entries {
+		name := d.Name
+		if d.IsDir() {
+			name += "/"
+		}
+
+		u := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(name))
+	}
+
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// localRedirect gives a relative redirect to newPath, keeping the query.
+func localRedirect(w http.ResponseWriter, req *http.Request, newPath string) {
+	if q := req.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
@@ -0,0 +1,121 @@
+package siva_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type HTTPSuite struct {
+	f      *os.File
+	server *httptest.Server
+}
+
+var _ = Suite(&HTTPSuite{})
+
+func (s *HTTPSuite) SetUpTest(c *C) {
+	var err error
+	s.f, err = os.Open("fixtures/dirs.siva")
+	c.Assert(err, IsNil)
+
+	s.server = httptest.NewServer(siva.HTTPHandler(siva.NewReader(s.f)))
+}
+
+func (s *HTTPSuite) TearDownTest(c *C) {
+	s.server.Close()
+	c.Assert(s.f.Close(), IsNil)
+}
+
+func (s *HTTPSuite) TestFile(c *C) {
+	res, body := s.get(c, "/letters/a", nil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(body, Equals, "a\n")
+	c.Assert(res.Header.Get("ETag"), Matches, `"[0-9a-f]{8}"`)
+	c.Assert(res.Header.Get("Last-Modified"), Not(Equals), "")
+
+	res, body = s.get(c, "/letters/a", map[string]string{
+		"If-None-Match": res.Header.Get("ETag"),
+	})
+	c.Assert(res.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(body, Equals, "")
+}
+
+func (s *HTTPSuite) TestRange(c *C) {
+	res, body := s.get(c, "/file.txt", map[string]string{"Range": "bytes=1-2"})
+	c.Assert(res.StatusCode, Equals, http.StatusPartialContent)
+	c.Assert(res.Header.Get("Content-Range"), Equals, "bytes 1-2/5")
+	c.Assert(body, HasLen, 2)
+}
+
+func (s *HTTPSuite) TestDir(c *C) {
+	res, body := s.get(c, "/", nil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(body, Equals, "<pre>\n"+
+		"<a href=\"file.txt\">file.txt</a>\n"+
+		"<a href=\"letters/\">letters/</a>\n"+
+		"<a href=\"numbers/\">numbers/</a>\n"+
+		"</pre>\n")
+
+	res, body = s.get(c, "/numbers/", nil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(strings.Count(body, "<a href"), Equals, 3)
+}
+
+func (s *HTTPSuite) TestRedirect(c *C) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(s.server.URL + "/letters")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusMovedPermanently)
+	c.Assert(res.Header.Get("Location"), Equals, "letters/")
+	c.Assert(res.Body.Close(), IsNil)
+
+	res, err = client.Get(s.server.URL + "/letters/a/")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusMovedPermanently)
+	c.Assert(res.Header.Get("Location"), Equals, "../a")
+	c.Assert(res.Body.Close(), IsNil)
+
+	res, _ = s.get(c, "/letters/a/", nil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(res.Request.URL.Path, Equals, "/letters/a")
+}
+
+func (s *HTTPSuite) TestNotFound(c *C) {
+	res, _ := s.get(c, "/foo", nil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *HTTPSuite) TestMethodNotAllowed(c *C) {
+	res, err := http.Post(s.server.URL+"/file.txt", "text/plain", nil)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusMethodNotAllowed)
+	c.Assert(res.Body.Close(), IsNil)
+}
+
+func (s *HTTPSuite) get(c *C, path string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", s.server.URL+path, nil)
+	c.Assert(err, IsNil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+
+	body, err := ioutil.ReadAll(res.Body)
+	c.Assert(err, IsNil)
+	c.Assert(res.Body.Close(), IsNil)
+
+	return res, string(body)
+}
@@ -20,6 +20,7 @@ var (
 	ErrEmptyIndex              = errors.New("empty index")
 	ErrUnsupportedIndexVersion = errors.New("unsupported index version")
 	ErrCRC32Missmatch          = errors.New("crc32 mismatch")
+	ErrTruncated               = errors.New("siva file was truncated")
 )
 
 const (
@@ -230,17 +231,18 @@ func (i Index) Find(name string) *IndexEntry {
 }
 
 // Glob returns all index entries whose name matches pattern or nil if there is
-// no matching entry. The syntax of patterns is the same as in filepath.Match.
+// no matching entry. The syntax of patterns is the same as in path.Match,
+// extended with `**` segments, matching zero or more directories, and brace
+// alternation, {a,b}.
 func (i Index) Glob(pattern string) ([]*IndexEntry, error) {
-	pattern = ToSafePath(pattern)
+	g, err := compileGlob(ToSafePath(pattern))
+	if err != nil {
+		return nil, err
+	}
+
 	matches := []*IndexEntry{}
 	for _, e := range i {
-		m, err := path.Match(pattern, e.Name)
-		if err != nil {
-			return nil, err
-		}
-
-		if m {
+		if g.Match(e.Name) {
 			matches = append(matches, e)
 		}
 	}
@@ -312,7 +314,7 @@ func (o OrderedIndex) Delete(path string) OrderedIndex {
 	}
 
 	pos := o.Pos(path)
-	if pos < len(o) && o[pos].Name != path {
+	if pos >= len(o) || o[pos].Name != path {
 		return o
 	}
 
@@ -334,6 +336,8 @@ func (o OrderedIndex) Find(path string) *IndexEntry {
 	return nil
 }
 
+// globPrefix returns the literal prefix of pattern. A `**` segment can match
+// zero directories, so the slash before it is not part of the prefix.
 func globPrefix(pattern string) string {
 	slash := false
 	for i, c := range pattern {
@@ -341,10 +345,16 @@ func globPrefix(pattern string) string {
 		case '\\':
 			slash = true
 			continue
-		case '*', '?', '[':
-			if !slash {
-				return pattern[:i]
+		case '*', '?', '[', '{':
+			if slash {
+				break
+			}
+
+			if isDoubleStarSegment(pattern, i) {
+				return pattern[:i-1]
 			}
+
+			return pattern[:i]
 		}
 
 		slash = false
@@ -353,29 +363,35 @@ func globPrefix(pattern string) string {
 	return pattern
 }
 
+// isDoubleStarSegment reports whether the `**` segment of pattern starts at i,
+// after a slash.
+func isDoubleStarSegment(pattern string, i int) bool {
+	if i == 0 || pattern[i-1] != '/' || !strings.HasPrefix(pattern[i:], "**") {
+		return false
+	}
+
+	rest := pattern[i+2:]
+	return rest == "" || rest[0] == '/'
+}
+
 // Glob returns all index entries whose name matches pattern or nil if there is
-// no matching entry. The syntax of patterns is the same as in filepath.Match.
+// no matching entry. The syntax of patterns is the same as in Index.Glob. Only
+// the entries sharing the literal prefix of the pattern are scanned.
 func (o OrderedIndex) Glob(pattern string) ([]*IndexEntry, error) {
 	pattern = ToSafePath(pattern)
-	prefix := globPrefix(pattern)
+	g, err := compileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
 
+	prefix := globPrefix(pattern)
 	matches := []*IndexEntry{}
-	first := o.Pos(prefix)
-
-	if first == -1 {
-		return matches, nil
-	}
-	for _, e := range o[first:] {
+	for _, e := range o[o.Pos(prefix):] {
 		if !strings.HasPrefix(e.Name, prefix) {
 			break
 		}
 
-		m, err := path.Match(pattern, e.Name)
-		if err != nil {
-			return nil, err
-		}
-
-		if m {
+		if g.Match(e.Name) {
 			matches = append(matches, e)
 		}
 	}
@@ -539,16 +555,28 @@ func readIndex(r io.ReadSeeker, offset uint64) (Index, error) {
 }
 
 func readIndexAt(r io.ReadSeeker, offset uint64) (Index, error) {
+	return readIndexRange(r, 0, offset)
+}
+
+// readIndexRange loads the indexes of the blocks between the given offsets,
+// following the chain backwards from the block ending at end until reaching
+// the block ending at start.
+func readIndexRange(r io.ReadSeeker, start, end uint64) (Index, error) {
 	i := make(Index, 0)
-	if err := i.ReadFrom(r, offset); err != nil {
+	if err := i.ReadFrom(r, end); err != nil {
 		return nil, err
 	}
 
-	if len(i) == 0 || i[0].absStart == 0 {
+	if len(i) == 0 {
+		return i, nil
+	}
+
+	blockStart := i[0].absStart - i[0].Start
+	if blockStart <= start {
 		return i, nil
 	}
 
-	previ, err := readIndexAt(r, i[0].absStart)
+	previ, err := readIndexRange(r, start, blockStart)
 	if err != nil {
 		return nil, err
 	}
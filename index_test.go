@@ -113,6 +113,23 @@ func (s *IndexSuite) TestFind(c *C) {
 	c.Assert(e.Start, Equals, uint64(3))
 }
 
+func (s *IndexSuite) TestOrderedIndexDeleteMissing(c *C) {
+	o := OrderedIndex{
+		{Header: Header{Name: "bar"}},
+		{Header: Header{Name: "foo"}},
+	}
+
+	o = o.Delete("qux")
+	c.Assert(o, HasLen, 2)
+
+	o = o.Delete("baz")
+	c.Assert(o, HasLen, 2)
+
+	o = o.Delete("bar")
+	c.Assert(o, HasLen, 1)
+	c.Assert(o[0].Name, Equals, "foo")
+}
+
 func (s *IndexSuite) TestToSafePaths(c *C) {
 	i := Index{
 		{Header: Header{Name: `C:\foo\bar`}, Start: 1},
@@ -190,6 +207,26 @@ func (s *IndexSuite) TestGlobPrefix(c *C) {
 			pattern:  "pat\\[tern[",
 			expected: "pat\\[tern",
 		},
+		{
+			pattern:  "pat{tern,ron}",
+			expected: "pat",
+		},
+		{
+			pattern:  "src/**/*.go",
+			expected: "src",
+		},
+		{
+			pattern:  "src/**",
+			expected: "src",
+		},
+		{
+			pattern:  "src/a**",
+			expected: "src/a",
+		},
+		{
+			pattern:  "src/**.go",
+			expected: "src/",
+		},
 	}
 
 	for _, test := range tests {
@@ -197,6 +234,32 @@ func (s *IndexSuite) TestGlobPrefix(c *C) {
 	}
 }
 
+func (s *IndexSuite) TestOrderedIndexGlobMatchesIndexGlob(c *C) {
+	var i Index
+	for _, name := range []string{
+		"src", "src.go", "src/a.go", "src/b/c.go", "src/b/d.txt", "srcs/e.go",
+		"dir/file.txt", "dir/sub/file.txt", "file.txt",
+	} {
+		i = append(i, &IndexEntry{Header: Header{Name: name}})
+	}
+
+	o := OrderedIndex(append(Index(nil), i...))
+	o.Sort()
+
+	for _, pattern := range []string{
+		"src/**", "src/**/*.go", "src/**/b/*", "src*", "src/*", "**",
+		"**/file.txt", "dir/**/file.txt", "{src,dir}/**", "src/**.go",
+	} {
+		expected, err := i.Glob(pattern)
+		c.Assert(err, IsNil)
+		OrderedIndex(expected).Sort()
+
+		obtained, err := o.Glob(pattern)
+		c.Assert(err, IsNil)
+		c.Assert(obtained, DeepEquals, expected, Commentf("pattern %q", pattern))
+	}
+}
+
 func BenchmarkGlob5(b *testing.B) {
 	benchmarkGlob(0, b)
 }
@@ -0,0 +1,190 @@
+package siva
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	ErrMmapUnsupported = errors.New("memory mapping is not supported on this platform")
+	ErrClosedMmap      = errors.New("memory mapped file is closed")
+	ErrOutOfBounds     = errors.New("entry is out of the mapped region")
+)
+
+// MmapReader is a Reader over a read-only memory mapping of a siva file. The
+// content of the entries can be accessed without copying using Bytes.
+//
+// The mapping only covers the size of the file at the moment it was mapped,
+// call Remap to see blocks appended later. Remap and Close invalidate the
+// slices returned by Bytes and the readers returned by Get, so MmapReader is
+// not a Refresher and can't be used with Watch.
+type MmapReader struct {
+	f *os.File
+
+	mutex  sync.RWMutex
+	data   []byte
+	r      Reader
+	closed bool
+}
+
+// OpenMmap opens the named siva file and maps it into memory.
+func OpenMmap(path string) (*MmapReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MmapReader{f: f}
+	if err := m.mmap(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// mmap maps the current size of the file, replacing data and r only if it
+// succeeds.
+func (m *MmapReader) mmap() error {
+	fi, err := m.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if fi.Size() > 0 {
+		data, err = mmap(m.f, fi.Size())
+		if err != nil {
+			return err
+		}
+	}
+
+	m.data = data
+	m.r = NewReaderAt(bytes.NewReader(data), int64(len(data)))
+	return nil
+}
+
+func (m *MmapReader) munmap(data []byte) error {
+	if data == nil {
+		return nil
+	}
+
+	return munmap(data)
+}
+
+// Remap maps the file again if its size changed since it was mapped, so the
+// blocks appended since then are visible. It returns true if the file was
+// remapped. The previous mapping is kept if the new one fails.
+func (m *MmapReader) Remap() (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return false, ErrClosedMmap
+	}
+
+	fi, err := m.f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if fi.Size() == int64(len(m.data)) {
+		return false, nil
+	}
+
+	old := m.data
+	if err := m.mmap(); err != nil {
+		return false, err
+	}
+
+	return true, m.munmap(old)
+}
+
+// Index returns the filtered index of the mapped siva file.
+func (m *MmapReader) Index() (Index, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return nil, ErrClosedMmap
+	}
+
+	return m.r.Index()
+}
+
+// Bytes returns the content of the entry as a slice of the mapping, without
+// copying it. The slice must not be modified and is only valid until the next
+// call to Remap or Close.
+func (m *MmapReader) Bytes(e *IndexEntry) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return nil, ErrClosedMmap
+	}
+
+	end := e.absStart + e.Size
+	if end < e.absStart || end > uint64(len(m.data)) {
+		return nil, ErrOutOfBounds
+	}
+
+	return m.data[e.absStart:end:end], nil
+}
+
+// Get returns a new io.SectionReader over the mapped content of the entry, it
+// is only valid until the next call to Remap or Close.
+func (m *MmapReader) Get(e *IndexEntry) (*io.SectionReader, error) {
+	b, err := m.Bytes(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NewSectionReader(bytes.NewReader(b), 0, int64(len(b))), nil
+}
+
+// Seek seeks the shared cursor to the starting position of the content for
+// the given IndexEntry.
+func (m *MmapReader) Seek(e *IndexEntry) (int64, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return 0, ErrClosedMmap
+	}
+
+	return m.r.Seek(e)
+}
+
+// Read reads from the shared cursor set by Seek.
+func (m *MmapReader) Read(p []byte) (int, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return 0, ErrClosedMmap
+	}
+
+	return m.r.Read(p)
+}
+
+// Close unmaps and closes the file.
+func (m *MmapReader) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return ErrClosedMmap
+	}
+
+	m.closed = true
+	err := m.munmap(m.data)
+	m.data = nil
+	if errC := m.f.Close(); errC != nil && err == nil {
+		err = errC
+	}
+
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package siva
+
+import "os"
+
+func mmap(f *os.File, size int64) ([]byte, error) {
+	return nil, ErrMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return ErrMmapUnsupported
+}
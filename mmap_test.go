@@ -0,0 +1,112 @@
+//go:build linux
+// +build linux
+
+package siva_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type MmapSuite struct{}
+
+var _ = Suite(&MmapSuite{})
+
+func (s *MmapSuite) TestBytes(c *C) {
+	m, err := siva.OpenMmap("fixtures/blocks.siva")
+	c.Assert(err, IsNil)
+
+	i, err := m.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+
+	e := i.Find("gopher.txt")
+	c.Assert(e, NotNil)
+
+	b, err := m.Bytes(e)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "Gopher names:\nGeorge\nGeoffrey\nGonzo")
+
+	sr, err := m.Get(e)
+	c.Assert(err, IsNil)
+	content, err := ioutil.ReadAll(sr)
+	c.Assert(err, IsNil)
+	c.Assert(content, DeepEquals, b)
+
+	c.Assert(m.Close(), IsNil)
+
+	_, err = m.Bytes(e)
+	c.Assert(err, Equals, siva.ErrClosedMmap)
+}
+
+func (s *MmapSuite) TestRemap(c *C) {
+	path := filepath.Join(c.MkDir(), "remap.siva")
+
+	f, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "foo", "foo")
+	c.Assert(f.Close(), IsNil)
+
+	m, err := siva.OpenMmap(path)
+	c.Assert(err, IsNil)
+
+	i, err := m.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 1)
+
+	remapped, err := m.Remap()
+	c.Assert(err, IsNil)
+	c.Assert(remapped, Equals, false)
+
+	f, err = siva.OpenFile(path, os.O_RDWR)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "bar", "bar")
+	writeEntry(c, f, "foo", "qux")
+	c.Assert(f.Close(), IsNil)
+
+	i, err = m.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 1)
+
+	remapped, err = m.Remap()
+	c.Assert(err, IsNil)
+	c.Assert(remapped, Equals, true)
+
+	i, err = m.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 2)
+
+	b, err := m.Bytes(i.Find("foo"))
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "qux")
+
+	c.Assert(m.Close(), IsNil)
+	c.Assert(m.Close(), Equals, siva.ErrClosedMmap)
+	_, err = m.Remap()
+	c.Assert(err, Equals, siva.ErrClosedMmap)
+}
+
+func (s *MmapSuite) TestNotRefresher(c *C) {
+	var r siva.Reader = &siva.MmapReader{}
+	_, ok := r.(siva.Refresher)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MmapSuite) TestEmpty(c *C) {
+	path := filepath.Join(c.MkDir(), "empty.siva")
+	c.Assert(ioutil.WriteFile(path, nil, 0666), IsNil)
+
+	m, err := siva.OpenMmap(path)
+	c.Assert(err, IsNil)
+
+	i, err := m.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 0)
+
+	c.Assert(m.Close(), IsNil)
+}
@@ -0,0 +1,182 @@
+package siva
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// EntryError is returned by Verify when the content of an entry doesn't
+// match its index.
+type EntryError struct {
+	Name string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+// NewStorageReader returns a Reader over the current content of s. The size
+// of s is read once, content appended later is not visible to the Reader.
+func NewStorageReader(s Storage) (Reader, error) {
+	size, err := s.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReaderAt(s, size), nil
+}
+
+// Append calls fn with a Writer appending a new block to s, holding an
+// exclusive lock on it. The block is closed and synced when fn returns. If fn
+// or the writing of the block fails, s is truncated back to its original size.
+// The lock is waited for up to timeout, a zero timeout waits forever.
+func Append(s Storage, timeout time.Duration, fn func(Writer) error) (err error) {
+	if err := s.Lock(true, timeout); err != nil {
+		return err
+	}
+
+	defer func() {
+		if uerr := s.Unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	size, err := s.Size()
+	if err != nil {
+		return err
+	}
+
+	w := NewWriter(s)
+	if err = fn(w); err == nil {
+		if err = w.Close(); err == nil {
+			return s.Sync()
+		}
+	}
+
+	if terr := s.Truncate(size); terr != nil {
+		return fmt.Errorf("%s, and restoring the previous size failed: %s", err, terr)
+	}
+
+	return err
+}
+
+// Compact writes to dst a single block siva file holding the live entries of
+// src, discarding deleted entries and previous versions of overwritten ones.
+// The previous content of dst is discarded, dst and src can't be the same
+// Storage. Each lock is waited for up to timeout, a zero timeout waits forever.
+func Compact(dst, src Storage, timeout time.Duration) (err error) {
+	if err := src.Lock(false, timeout); err != nil {
+		return err
+	}
+
+	defer func() {
+		if uerr := src.Unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	r, err := NewStorageReader(src)
+	if err != nil {
+		return err
+	}
+
+	i, err := r.Index()
+	if err != nil {
+		return err
+	}
+
+	if err := dst.Lock(true, timeout); err != nil {
+		return err
+	}
+
+	defer func() {
+		if uerr := dst.Unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	if err := dst.Truncate(0); err != nil {
+		return err
+	}
+
+	w := NewWriter(dst)
+	for _, e := range i.Filter() {
+		if err := copyEntry(w, r, e); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return dst.Sync()
+}
+
+func copyEntry(w Writer, r Reader, e *IndexEntry) error {
+	if err := w.WriteHeader(&e.Header); err != nil {
+		return err
+	}
+
+	content, err := r.Get(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// Verify checks the index of every block of s and the checksum of every
+// entry, including deleted and overwritten ones. The first entry not matching
+// its checksum is returned as an *EntryError. The lock is waited for up to
+// timeout, a zero timeout waits forever.
+func Verify(s Storage, timeout time.Duration) (err error) {
+	if err := s.Lock(false, timeout); err != nil {
+		return err
+	}
+
+	defer func() {
+		if uerr := s.Unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	size, err := s.Size()
+	if err != nil {
+		return err
+	}
+
+	i, err := ReadRawIndex(s, size)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range i {
+		if err := verifyEntry(s, e); err != nil {
+			return &EntryError{Name: e.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func verifyEntry(ra io.ReaderAt, e *IndexEntry) error {
+	hr := newHashedReader(io.NewSectionReader(ra, int64(e.absStart), int64(e.Size)))
+	n, err := io.Copy(io.Discard, hr)
+	if err != nil {
+		return err
+	}
+
+	if uint64(n) != e.Size {
+		return ErrTruncated
+	}
+
+	if hr.Checkshum() != e.CRC32 {
+		return ErrInvalidCheckshum
+	}
+
+	return nil
+}
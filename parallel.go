@@ -0,0 +1,257 @@
+package siva
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrEntryNotClosed = errors.New("entry writer wasn't closed")
+)
+
+// DefaultSpillThreshold is the size after which the content of an entry
+// staged by a ParallelWriter is moved from memory to a temporary file.
+const DefaultSpillThreshold = 4 * 1024 * 1024
+
+// ParallelWriter stages entries written concurrently and writes them to a
+// Writer as a single block when closed. Every entry is buffered in memory
+// until it reaches SpillThreshold, then in a temporary file. The entries are
+// written sorted by name, and by creation order for entries with the same
+// name, so the result doesn't depend on the scheduling of the producers.
+type ParallelWriter struct {
+	// SpillThreshold is the size of the content of an entry kept in memory,
+	// the rest is written to a temporary file. Negative values keep all the
+	// content in memory.
+	SpillThreshold int64
+	// TempDir is the directory of the temporary files, the default directory
+	// for temporary files is used when empty.
+	TempDir string
+
+	w       Writer
+	mutex   sync.Mutex
+	entries []*stagedEntry
+	closed  bool
+}
+
+// NewParallelWriter creates a new ParallelWriter writing to w. If w is a
+// Committer the block is committed on Close, but w is never closed.
+func NewParallelWriter(w Writer) *ParallelWriter {
+	return &ParallelWriter{
+		SpillThreshold: DefaultSpillThreshold,
+		w:              w,
+	}
+}
+
+// Create starts a new entry with the given header, its content should be
+// written to the returned io.WriteCloser, which must be closed before closing
+// the ParallelWriter. Create is safe for concurrent use, each of the returned
+// writers should be used by a single goroutine.
+func (p *ParallelWriter) Create(h *Header) (io.WriteCloser, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil, ErrClosedWriter
+	}
+
+	e := &stagedEntry{
+		header:    *h,
+		threshold: p.SpillThreshold,
+		tempDir:   p.TempDir,
+		crc:       crc32.NewIEEE(),
+	}
+
+	e.header.Name = ToSafePath(h.Name)
+	p.entries = append(p.entries, e)
+	return e, nil
+}
+
+// Close writes all the staged entries to the underlying Writer and commits
+// them as a single block if the Writer is a Committer, otherwise the entries
+// are sealed as the Writer would do with any other entry. ErrEntryNotClosed is
+// returned if any of the entries is still open. The temporary files are removed even if writing fails.
+func (p *ParallelWriter) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return ErrClosedWriter
+	}
+
+	p.closed = true
+	defer p.cleanup()
+
+	for _, e := range p.entries {
+		if !e.isClosed() {
+			return ErrEntryNotClosed
+		}
+	}
+
+	sort.SliceStable(p.entries, func(i, j int) bool {
+		return p.entries[i].header.Name < p.entries[j].header.Name
+	})
+
+	for _, e := range p.entries {
+		if err := e.writeTo(p.w); err != nil {
+			return err
+		}
+	}
+
+	if c, ok := p.w.(Committer); ok {
+		return c.Commit()
+	}
+
+	return nil
+}
+
+// Abort discards all the staged entries, removing their temporary files.
+// Nothing is written to the underlying Writer.
+func (p *ParallelWriter) Abort() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return ErrClosedWriter
+	}
+
+	p.closed = true
+	p.cleanup()
+	return nil
+}
+
+func (p *ParallelWriter) cleanup() {
+	for _, e := range p.entries {
+		e.remove()
+	}
+
+	p.entries = nil
+}
+
+type stagedEntry struct {
+	header    Header
+	threshold int64
+	tempDir   string
+	crc       hash.Hash32
+
+	buf    bytes.Buffer
+	file   *os.File
+	mutex  sync.Mutex
+	closed bool
+}
+
+// Write stages p as content of the entry, its checksum is computed as it is
+// written, so the producers don't wait for it on Close.
+func (e *stagedEntry) Write(p []byte) (int, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return 0, ErrClosedWriter
+	}
+
+	n, err := e.write(p)
+	e.crc.Write(p[:n])
+	return n, err
+}
+
+func (e *stagedEntry) write(p []byte) (int, error) {
+	if e.file != nil {
+		return e.file.Write(p)
+	}
+
+	if e.threshold >= 0 && int64(e.buf.Len()+len(p)) > e.threshold {
+		if err := e.spill(); err != nil {
+			return 0, err
+		}
+
+		return e.file.Write(p)
+	}
+
+	return e.buf.Write(p)
+}
+
+func (e *stagedEntry) spill() error {
+	f, err := ioutil.TempFile(e.tempDir, "siva-entry-")
+	if err != nil {
+		return err
+	}
+
+	e.file = f
+	if _, err := e.buf.WriteTo(f); err != nil {
+		return err
+	}
+
+	e.buf = bytes.Buffer{}
+	return nil
+}
+
+// Close finishes the entry.
+func (e *stagedEntry) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return ErrClosedWriter
+	}
+
+	e.closed = true
+	return nil
+}
+
+func (e *stagedEntry) isClosed() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.closed
+}
+
+type entryWriter interface {
+	writeEntry(h *Header, r io.Reader, crc uint32) error
+}
+
+func (e *stagedEntry) writeTo(w Writer) error {
+	var content io.Reader = &e.buf
+	if e.file != nil {
+		if _, err := e.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		content = e.file
+	}
+
+	if ew, ok := w.(entryWriter); ok {
+		return ew.writeEntry(&e.header, content, e.crc.Sum32())
+	}
+
+	if err := w.WriteHeader(&e.header); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, content); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+func (e *stagedEntry) remove() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.closed = true
+	e.buf = bytes.Buffer{}
+	if e.file == nil {
+		return
+	}
+
+	e.file.Close()
+	os.Remove(e.file.Name())
+	e.file = nil
+}
@@ -0,0 +1,183 @@
+package siva_test
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type ParallelWriterSuite struct{}
+
+var _ = Suite(&ParallelWriterSuite{})
+
+func (s *ParallelWriterSuite) TestWrite(c *C) {
+	tmpDir := c.MkDir()
+	first := s.write(c, tmpDir)
+	second := s.write(c, tmpDir)
+	c.Assert(bytes.Equal(first, second), Equals, true)
+
+	fis, err := ioutil.ReadDir(tmpDir)
+	c.Assert(err, IsNil)
+	c.Assert(fis, HasLen, 0)
+
+	r := siva.NewReader(bytes.NewReader(first))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 50)
+	c.Assert(i.Blocks(), HasLen, 1)
+
+	for n, e := range i {
+		c.Assert(e.Name, Equals, fmt.Sprintf("file-%02d", n))
+
+		content, err := r.Get(e)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(content)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, strings.Repeat(fmt.Sprint(n%10), n*10))
+	}
+}
+
+func (s *ParallelWriterSuite) write(c *C, tmpDir string) []byte {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	pw := siva.NewParallelWriter(w)
+	pw.SpillThreshold = 100
+	pw.TempDir = tmpDir
+
+	var wg sync.WaitGroup
+	for n := 49; n >= 0; n-- {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			ew, err := pw.Create(&siva.Header{
+				Name:    fmt.Sprintf("file-%02d", n),
+				ModTime: time.Unix(0, 0),
+			})
+			c.Check(err, IsNil)
+
+			for i := 0; i < n; i++ {
+				_, err := ew.Write([]byte(strings.Repeat(fmt.Sprint(n%10), 10)))
+				c.Check(err, IsNil)
+			}
+
+			c.Check(ew.Close(), IsNil)
+		}(n)
+	}
+
+	wg.Wait()
+	c.Assert(pw.Close(), IsNil)
+	c.Assert(w.Close(), IsNil)
+	return buf.Bytes()
+}
+
+func (s *ParallelWriterSuite) TestDuplicatedNames(c *C) {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	pw := siva.NewParallelWriter(w)
+
+	for _, content := range []string{"foo", "bar"} {
+		ew, err := pw.Create(&siva.Header{Name: "file"})
+		c.Assert(err, IsNil)
+		_, err = ew.Write([]byte(content))
+		c.Assert(err, IsNil)
+		c.Assert(ew.Close(), IsNil)
+	}
+
+	c.Assert(pw.Close(), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	r := siva.NewReader(bytes.NewReader(buf.Bytes()))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	e := i.Find("file")
+	c.Assert(e, NotNil)
+
+	content, err := r.Get(e)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(content)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "bar")
+}
+
+func (s *ParallelWriterSuite) TestNonCommitter(c *C) {
+	committed := s.writeFiles(c, func(w siva.Writer) siva.Writer { return w })
+	plain := s.writeFiles(c, func(w siva.Writer) siva.Writer {
+		return struct{ siva.Writer }{w}
+	})
+
+	c.Assert(bytes.Equal(committed, plain), Equals, true)
+
+	i, err := siva.NewReader(bytes.NewReader(plain)).Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 2)
+	c.Assert(i[0].CRC32, Equals, crc32.ChecksumIEEE([]byte("bar")))
+	c.Assert(i[1].CRC32, Equals, crc32.ChecksumIEEE([]byte("foo")))
+}
+
+func (s *ParallelWriterSuite) writeFiles(c *C, wrap func(siva.Writer) siva.Writer) []byte {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	pw := siva.NewParallelWriter(wrap(w))
+
+	for _, name := range []string{"foo", "bar"} {
+		ew, err := pw.Create(&siva.Header{Name: name, ModTime: time.Unix(0, 0)})
+		c.Assert(err, IsNil)
+		_, err = ew.Write([]byte(name))
+		c.Assert(err, IsNil)
+		c.Assert(ew.Close(), IsNil)
+	}
+
+	c.Assert(pw.Close(), IsNil)
+	c.Assert(w.Close(), IsNil)
+	return buf.Bytes()
+}
+
+func (s *ParallelWriterSuite) TestEntryNotClosed(c *C) {
+	buf := new(bytes.Buffer)
+	pw := siva.NewParallelWriter(siva.NewWriter(buf))
+
+	ew, err := pw.Create(&siva.Header{Name: "file"})
+	c.Assert(err, IsNil)
+
+	c.Assert(pw.Close(), Equals, siva.ErrEntryNotClosed)
+	c.Assert(buf.Len(), Equals, 0)
+
+	_, err = pw.Create(&siva.Header{Name: "other"})
+	c.Assert(err, Equals, siva.ErrClosedWriter)
+	c.Assert(ew.Close(), Equals, siva.ErrClosedWriter)
+}
+
+func (s *ParallelWriterSuite) TestAbort(c *C) {
+	tmpDir := c.MkDir()
+	buf := new(bytes.Buffer)
+	pw := siva.NewParallelWriter(siva.NewWriter(buf))
+	pw.SpillThreshold = 0
+	pw.TempDir = tmpDir
+
+	ew, err := pw.Create(&siva.Header{Name: "file"})
+	c.Assert(err, IsNil)
+	_, err = ew.Write([]byte("foo"))
+	c.Assert(err, IsNil)
+
+	fis, err := ioutil.ReadDir(tmpDir)
+	c.Assert(err, IsNil)
+	c.Assert(fis, HasLen, 1)
+
+	c.Assert(pw.Abort(), IsNil)
+	c.Assert(buf.Len(), Equals, 0)
+
+	fis, err = ioutil.ReadDir(tmpDir)
+	c.Assert(err, IsNil)
+	c.Assert(fis, HasLen, 0)
+
+	c.Assert(pw.Close(), Equals, siva.ErrClosedWriter)
+}
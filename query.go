@@ -0,0 +1,173 @@
+package siva
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Predicate reports whether an IndexEntry matches some criteria. Predicates
+// can be combined with its And, Or and Not methods, and applied to an Index
+// with Query.
+type Predicate func(e *IndexEntry) bool
+
+// Query returns the entries of the index matching p, keeping their order. It
+// can be used both with filtered indexes and with raw ones, as returned by
+// ReadRawIndex.
+func (i Index) Query(p Predicate) Index {
+	var result Index
+	for _, e := range i {
+		if p(e) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// And returns a Predicate matching the entries matched by p and all the given
+// predicates.
+func (p Predicate) And(ps ...Predicate) Predicate {
+	return func(e *IndexEntry) bool {
+		if !p(e) {
+			return false
+		}
+
+		for _, p := range ps {
+			if !p(e) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Or returns a Predicate matching the entries matched by p or any of the
+// given predicates.
+func (p Predicate) Or(ps ...Predicate) Predicate {
+	return func(e *IndexEntry) bool {
+		if p(e) {
+			return true
+		}
+
+		for _, p := range ps {
+			if p(e) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Not returns a Predicate matching the entries not matched by p.
+func (p Predicate) Not() Predicate {
+	return func(e *IndexEntry) bool {
+		return !p(e)
+	}
+}
+
+// NameGlob returns a Predicate matching the entries whose name matches the
+// pattern, with the same syntax as in Index.Glob.
+func NameGlob(pattern string) (Predicate, error) {
+	g, err := compileGlob(ToSafePath(pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e *IndexEntry) bool {
+		return g.Match(e.Name)
+	}, nil
+}
+
+// NameRegexp returns a Predicate matching the entries whose name matches re.
+func NameRegexp(re *regexp.Regexp) Predicate {
+	return func(e *IndexEntry) bool {
+		return re.MatchString(e.Name)
+	}
+}
+
+// SizeRange returns a Predicate matching the entries with a size in the range
+// [min, max), a zero max means no upper limit.
+func SizeRange(min, max uint64) Predicate {
+	return func(e *IndexEntry) bool {
+		return e.Size >= min && (max == 0 || e.Size < max)
+	}
+}
+
+// ModTimeRange returns a Predicate matching the entries with a ModTime in the
+// range [from, to), a zero time means no limit.
+func ModTimeRange(from, to time.Time) Predicate {
+	return func(e *IndexEntry) bool {
+		if !from.IsZero() && e.ModTime.Before(from) {
+			return false
+		}
+
+		return to.IsZero() || e.ModTime.Before(to)
+	}
+}
+
+// ModeBits returns a Predicate matching the entries with all the given mode
+// bits set, e.g. ModeBits(0100) matches the files executable by the owner.
+func ModeBits(mask os.FileMode) Predicate {
+	return func(e *IndexEntry) bool {
+		return e.Mode&mask == mask
+	}
+}
+
+// Flags returns a Predicate matching the entries with all the given flags
+// set, e.g. Flags(FlagDeleted) matches the deletion markers of a raw index.
+func Flags(f Flag) Predicate {
+	return func(e *IndexEntry) bool {
+		return e.Flags&f == f
+	}
+}
+
+// BlockRange returns a Predicate matching the entries written in a block
+// starting in the range of offsets [from, to), a zero to means no upper
+// limit.
+func BlockRange(from, to uint64) Predicate {
+	return func(e *IndexEntry) bool {
+		start := e.BlockOffset()
+		return start >= from && (to == 0 || start < to)
+	}
+}
+
+// BlockOffset returns the absolute offset where the block containing the
+// entry starts.
+func (e *IndexEntry) BlockOffset() uint64 {
+	return e.absStart - e.Start
+}
+
+// Blocks returns the sorted starting offsets of the blocks containing the
+// entries of the index.
+func (i Index) Blocks() []uint64 {
+	seen := make(map[uint64]bool)
+	var blocks []uint64
+	for _, e := range i {
+		start := e.BlockOffset()
+		if !seen[start] {
+			seen[start] = true
+			blocks = append(blocks, start)
+		}
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	return blocks
+}
+
+// ReadRawIndex reads all the entries of a siva file of the given size,
+// including the duplicated ones and the ones flagged as deleted, sorted by
+// their position in the file. Only ReadAt is used, so it's safe to use on a
+// file being written.
+func ReadRawIndex(ra io.ReaderAt, size int64) (Index, error) {
+	i, err := readIndex(io.NewSectionReader(ra, 0, size), uint64(size))
+	if err == ErrEmptyIndex {
+		return Index{}, nil
+	}
+
+	return i, err
+}
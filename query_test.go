@@ -0,0 +1,103 @@
+package siva
+
+import (
+	"os"
+	"regexp"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type QuerySuite struct{}
+
+var _ = Suite(&QuerySuite{})
+
+var (
+	queryTime  = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	queryIndex = Index{
+		{Header: Header{Name: "bin/run", Mode: 0755, ModTime: queryTime}, Size: 100},
+		{Header: Header{Name: "README.md", Mode: 0644, ModTime: queryTime.Add(time.Hour)}, Size: 10, absStart: 100},
+		{Header: Header{Name: "src/main.go", Mode: 0644, ModTime: queryTime.Add(2 * time.Hour)}, Size: 1000, absStart: 110},
+		{Header: Header{Name: "src/main.go", Mode: 0600, ModTime: queryTime.Add(3 * time.Hour)}, Size: 500, absStart: 1300},
+		{Header: Header{Name: "README.md", Flags: FlagDeleted, ModTime: queryTime.Add(3 * time.Hour)}, Start: 500, absStart: 1800},
+	}
+)
+
+func (s *QuerySuite) TestSizeRange(c *C) {
+	s.assertQuery(c, SizeRange(100, 0), "bin/run", "src/main.go", "src/main.go")
+	s.assertQuery(c, SizeRange(0, 100), "README.md", "README.md")
+	s.assertQuery(c, SizeRange(100, 1000), "bin/run", "src/main.go")
+}
+
+func (s *QuerySuite) TestModTimeRange(c *C) {
+	s.assertQuery(c, ModTimeRange(queryTime.Add(2*time.Hour), time.Time{}),
+		"src/main.go", "src/main.go", "README.md")
+	s.assertQuery(c, ModTimeRange(time.Time{}, queryTime.Add(time.Hour)), "bin/run")
+}
+
+func (s *QuerySuite) TestModeBits(c *C) {
+	s.assertQuery(c, ModeBits(0100), "bin/run")
+	s.assertQuery(c, ModeBits(0644), "bin/run", "README.md", "src/main.go")
+}
+
+func (s *QuerySuite) TestFlags(c *C) {
+	s.assertQuery(c, Flags(FlagDeleted), "README.md")
+	s.assertQuery(c, Flags(FlagDeleted).Not(),
+		"bin/run", "README.md", "src/main.go", "src/main.go")
+}
+
+func (s *QuerySuite) TestBlockRange(c *C) {
+	c.Assert(queryIndex.Blocks(), DeepEquals, []uint64{0, 100, 110, 1300})
+	s.assertQuery(c, BlockRange(1300, 0), "src/main.go", "README.md")
+	s.assertQuery(c, BlockRange(0, 110), "bin/run", "README.md")
+}
+
+func (s *QuerySuite) TestName(c *C) {
+	p, err := NameGlob("src/**/*.go")
+	c.Assert(err, IsNil)
+	s.assertQuery(c, p, "src/main.go", "src/main.go")
+
+	_, err = NameGlob("[")
+	c.Assert(err, NotNil)
+
+	s.assertQuery(c, NameRegexp(regexp.MustCompile(`^[A-Z]`)), "README.md", "README.md")
+}
+
+func (s *QuerySuite) TestCombine(c *C) {
+	p, err := NameGlob("**/*.go")
+	c.Assert(err, IsNil)
+
+	s.assertQuery(c, p.And(SizeRange(600, 0)), "src/main.go")
+	s.assertQuery(c, p.Or(ModeBits(0100)), "bin/run", "src/main.go", "src/main.go")
+	s.assertQuery(c, p.Or(ModeBits(0100)).Not(), "README.md", "README.md")
+}
+
+func (s *QuerySuite) TestRawIndex(c *C) {
+	f, err := os.Open("fixtures/overwritten.siva")
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	fi, err := f.Stat()
+	c.Assert(err, IsNil)
+
+	i, err := ReadRawIndex(f, fi.Size())
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 6)
+	c.Assert(i.Blocks(), DeepEquals, []uint64{0, 200})
+
+	c.Assert(i.Query(SizeRange(0, 10)), HasLen, 3)
+	c.Assert(i.Filter().Query(SizeRange(0, 10)), HasLen, 0)
+
+	i, err = ReadRawIndex(f, 0)
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 0)
+}
+
+func (s *QuerySuite) assertQuery(c *C, p Predicate, expected ...string) {
+	names := []string{}
+	for _, e := range queryIndex.Query(p) {
+		names = append(names, e.Name)
+	}
+
+	c.Assert(names, DeepEquals, expected)
+}
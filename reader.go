@@ -3,6 +3,8 @@ package siva
 import (
 	"errors"
 	"io"
+	"sort"
+	"sync"
 )
 
 var (
@@ -19,6 +21,17 @@ type Reader interface {
 	Get(e *IndexEntry) (*io.SectionReader, error)
 }
 
+// A Refresher is a Reader able to load the blocks appended to the siva file
+// after its index was read. The readers returned by NewReader implement it,
+// the ones with a fixed index, like those returned by NewReaderWithOffset,
+// NewReaderAt or OpenFile, don't.
+type Refresher interface {
+	Reader
+	// Refresh merges the indexes of the new blocks into the filtered index,
+	// returning true if it changed.
+	Refresh() (bool, error)
+}
+
 type reader struct {
 	r io.ReadSeeker
 
@@ -27,12 +40,13 @@ type reader struct {
 	current      *IndexEntry
 	pending      uint64
 	offset       uint64
+	end          uint64
 }
 
 // NewReader creates a new Reader reading from r, reader requires be seekable
 // and optionally should implement io.ReaderAt to make usage of the Get method
 func NewReader(r io.ReadSeeker) Reader {
-	return &reader{r: r}
+	return &refreshReader{&reader{r: r}}
 }
 
 // NewReaderWithOffset creates a new Reader giving the position of the index.
@@ -45,6 +59,20 @@ func NewReaderWithOffset(r io.ReadSeeker, o uint64) Reader {
 	}
 }
 
+// NewReaderAt creates a new Reader reading a siva file of the given size from
+// ra. The index and the contents are read using only ReadAt, so ra doesn't
+// need to be seekable. Index and Get are safe for concurrent use, Seek and Read
+// share a single cursor and are serialized.
+func NewReaderAt(ra io.ReaderAt, size int64) Reader {
+	return &readerAt{
+		reader: &reader{
+			r:      io.NewSectionReader(ra, 0, size),
+			offset: uint64(size),
+		},
+		ra: ra,
+	}
+}
+
 func newReaderWithIndex(r io.ReadSeeker, getIndexFunc func() (Index, error)) *reader {
 	return &reader{
 		r:            r,
@@ -59,7 +87,12 @@ func (r *reader) Index() (Index, error) {
 	}
 
 	if r.index == nil {
-		i, err := readIndex(r.r, r.offset)
+		end, err := r.indexEnd()
+		if err != nil {
+			return nil, err
+		}
+
+		i, err := readIndex(r.r, end)
 		if err != nil && err != ErrEmptyIndex {
 			return nil, err
 		}
@@ -67,11 +100,77 @@ func (r *reader) Index() (Index, error) {
 		index := OrderedIndex(i.filter())
 		index.Sort()
 		r.index = Index(index)
+		r.end = end
 	}
 
 	return r.index, nil
 }
 
+func (r *reader) indexEnd() (uint64, error) {
+	if r.offset != 0 {
+		return r.offset, nil
+	}
+
+	end, err := r.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(end), nil
+}
+
+// refreshReader is the Reader returned by NewReader, the only one whose index
+// can grow.
+type refreshReader struct {
+	*reader
+}
+
+// Refresh reads the indexes of the blocks appended to the siva file since the
+// index was read and merges them into the filtered index, returning true if
+// the index changed. The previously returned indexes are not modified. If the
+// new bytes don't end in a complete block yet, because a writer is in the
+// middle of appending it, the index is considered unchanged. Refresh moves the
+// position used by Read, so Seek should be called again afterwards.
+func (r *refreshReader) Refresh() (bool, error) {
+	if r.index == nil {
+		i, err := r.Index()
+		return len(i) != 0, err
+	}
+
+	end, err := r.indexEnd()
+	if err != nil {
+		return false, err
+	}
+
+	if end == r.end {
+		return false, nil
+	}
+
+	if end < r.end {
+		return false, ErrTruncated
+	}
+
+	i, err := readIndexRange(r.r, r.end, end)
+	if _, ok := err.(*IndexReadError); ok {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	sort.Sort(i)
+	index := make(OrderedIndex, len(r.index))
+	copy(index, r.index)
+	for _, e := range i {
+		index = index.Update(e)
+	}
+
+	r.index = Index(index)
+	r.end = end
+	return true, nil
+}
+
 // Get returns a new io.SectionReader allowing concurrent read access to the
 // content of the read
 func (r *reader) Get(e *IndexEntry) (*io.SectionReader, error) {
@@ -112,3 +211,45 @@ func (r *reader) Read(p []byte) (n int, err error) {
 
 	return
 }
+
+type readerAt struct {
+	*reader
+	ra io.ReaderAt
+
+	once  sync.Once
+	err   error
+	mutex sync.Mutex
+}
+
+// Index reads the index of the siva file, the index is read only once and
+// shared by all the callers.
+func (r *readerAt) Index() (Index, error) {
+	r.once.Do(func() {
+		_, r.err = r.reader.Index()
+	})
+
+	return r.index, r.err
+}
+
+// Get returns a new io.SectionReader allowing concurrent read access to the
+// content of the entry.
+func (r *readerAt) Get(e *IndexEntry) (*io.SectionReader, error) {
+	return io.NewSectionReader(r.ra, int64(e.absStart), int64(e.Size)), nil
+}
+
+// Seek moves the shared cursor to the starting position of the content for
+// the given IndexEntry.
+func (r *readerAt) Seek(e *IndexEntry) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.reader.Seek(e)
+}
+
+// Read reads from the shared cursor set by Seek.
+func (r *readerAt) Read(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.reader.Read(p)
+}
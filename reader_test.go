@@ -2,9 +2,12 @@ package siva
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
+	"sync"
 
 	. "gopkg.in/check.v1"
 )
@@ -112,6 +115,29 @@ func (s *ReaderSuite) testIndexGlob(c *C, ordered bool) {
 		"numbers/3",
 	})
 	s.testIndexGlobSingle(c, "nonexistent/*", ordered, []string{})
+	s.testIndexGlobSingle(c, "**", ordered, []string{
+		"file.txt",
+		"letters/a",
+		"letters/b",
+		"letters/c",
+		"numbers/1",
+		"numbers/2",
+		"numbers/3",
+	})
+	s.testIndexGlobSingle(c, "**/[ab1]", ordered, []string{
+		"letters/a",
+		"letters/b",
+		"numbers/1",
+	})
+	s.testIndexGlobSingle(c, "{letters,numbers}/{a,3}", ordered, []string{
+		"letters/a",
+		"numbers/3",
+	})
+	s.testIndexGlobSingle(c, "letters/**", ordered, []string{
+		"letters/a",
+		"letters/b",
+		"letters/c",
+	})
 }
 
 func (s *ReaderSuite) testIndexGlobSingle(
@@ -169,3 +195,92 @@ func (s *ReaderSuite) TestOffset(c *C) {
 	c.Assert(entry, NotNil)
 	c.Assert(entry.Size, Equals, uint64(35))
 }
+
+func (s *ReaderSuite) TestReaderAt(c *C) {
+	data, err := ioutil.ReadFile("fixtures/blocks.siva")
+	c.Assert(err, IsNil)
+
+	r := NewReaderAt(readerAtOnly{bytes.NewReader(data)}, int64(len(data)))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+
+	for j, e := range i {
+		c.Assert(e.Name, Equals, files[j].Name)
+
+		content, err := r.Get(e)
+		c.Assert(err, IsNil)
+
+		bytes, err := ioutil.ReadAll(content)
+		c.Assert(err, IsNil)
+		c.Assert(string(bytes), Equals, files[j].Body)
+
+		_, err = r.Seek(e)
+		c.Assert(err, IsNil)
+
+		bytes, err = ioutil.ReadAll(r)
+		c.Assert(err, IsNil)
+		c.Assert(string(bytes), Equals, files[j].Body)
+	}
+}
+
+func (s *ReaderSuite) TestReaderAtEmpty(c *C) {
+	r := NewReaderAt(readerAtOnly{bytes.NewReader(nil)}, 0)
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 0)
+}
+
+func (s *ReaderSuite) TestReaderAtConcurrent(c *C) {
+	data, err := ioutil.ReadFile("fixtures/blocks.siva")
+	c.Assert(err, IsNil)
+
+	r := NewReaderAt(readerAtOnly{bytes.NewReader(data)}, int64(len(data)))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i, err := r.Index()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			for j, e := range i {
+				content, err := r.Get(e)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				bytes, err := ioutil.ReadAll(content)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if string(bytes) != files[j].Body {
+					errs <- fmt.Errorf("unexpected content for %s", e.Name)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		c.Assert(err, IsNil)
+	}
+}
+
+type readerAtOnly struct {
+	ra io.ReaderAt
+}
+
+func (r readerAtOnly) ReadAt(p []byte, off int64) (int, error) {
+	return r.ra.ReadAt(p, off)
+}
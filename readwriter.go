@@ -26,12 +26,15 @@ func NewReaderWriter(rw io.ReadWriteSeeker) (*ReadWriter, error) {
 	}
 
 	w := newWriter(rw)
+	w.ordered = true
 	w.oIndex = OrderedIndex(i.filter())
 	w.oIndex.Sort()
+	w.committed = append(OrderedIndex(nil), w.oIndex...)
+	w.base = uint64(end)
 
 	getIndexFunc := func() (Index, error) {
 		for _, e := range w.index {
-			e.absStart = uint64(end) + e.Start
+			e.absStart = w.base + e.Start
 		}
 
 		return Index(w.oIndex), nil
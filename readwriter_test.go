@@ -2,6 +2,7 @@ package siva_test
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -234,3 +235,54 @@ func (s *ReadWriterSuite) TestDelete(c *C) {
 		}
 	}
 }
+
+func (s *ReadWriterSuite) TestCommitAbort(c *C) {
+	path := filepath.Join(s.tmpDir, c.TestName())
+	f, err := os.Create(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	rw, err := siva.NewReaderWriter(f)
+	c.Assert(err, IsNil)
+
+	writeEntry(c, rw, "foo", "bar")
+	writeEntry(c, rw, "qux", "baz")
+	c.Assert(rw.Commit(), IsNil)
+	committed, err := f.Seek(0, io.SeekEnd)
+	c.Assert(err, IsNil)
+
+	writeEntry(c, rw, "foo", "overwritten")
+	writeEntry(c, rw, "aborted", "aborted")
+	c.Assert(rw.Abort(), IsNil)
+
+	fi, err := f.Stat()
+	c.Assert(err, IsNil)
+	c.Assert(fi.Size(), Equals, committed)
+	s.assertContent(c, rw, map[string]string{"foo": "bar", "qux": "baz"})
+
+	writeEntry(c, rw, "foo", "committed")
+	c.Assert(rw.Commit(), IsNil)
+	s.assertContent(c, rw, map[string]string{"foo": "committed", "qux": "baz"})
+	c.Assert(rw.Close(), IsNil)
+
+	r := siva.NewReader(f)
+	s.assertContent(c, r, map[string]string{"foo": "committed", "qux": "baz"})
+}
+
+func (s *ReadWriterSuite) assertContent(c *C, r siva.Reader, expected map[string]string) {
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	i = i.Filter()
+	c.Assert(i, HasLen, len(expected))
+
+	for name, content := range expected {
+		e := i.Find(name)
+		c.Assert(e, NotNil)
+
+		sr, err := r.Get(e)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, content)
+	}
+}
@@ -0,0 +1,314 @@
+package siva
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrRangeNotSupported = errors.New("server doesn't support range requests")
+	ErrInvalidBlockSize  = errors.New("invalid block size, it must be greater than zero")
+)
+
+const (
+	// DefaultHTTPBlockSize is the size of the blocks requested and cached by
+	// an HTTPReaderAt.
+	DefaultHTTPBlockSize = 64 * 1024
+	// DefaultHTTPCacheBlocks is the number of blocks kept in memory by an
+	// HTTPReaderAt.
+	DefaultHTTPCacheBlocks = 256
+)
+
+// OpenURL returns a Reader for the siva file at the given URL, the server
+// must support HTTP range requests. Only the regions containing the indexes
+// are downloaded to read the index, the content of the entries is requested
+// on demand.
+func OpenURL(url string) (Reader, error) {
+	ra, err := NewHTTPReaderAt(http.DefaultClient, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReaderAt(ra, ra.Size()), nil
+}
+
+// HTTPReaderAt is an io.ReaderAt over a remote file, reading it with HTTP
+// range requests. The file is requested in aligned blocks, the most recently
+// used ones are cached. It is safe for concurrent use, concurrent reads of
+// the same block share a single request.
+type HTTPReaderAt struct {
+	// BlockSize is the size of the blocks requested and cached, it can only
+	// be changed before the first read.
+	BlockSize int64
+	// CacheBlocks is the maximum number of blocks cached, zero disables the
+	// cache.
+	CacheBlocks int
+
+	client *http.Client
+	url    string
+	size   int64
+
+	mutex    sync.Mutex
+	blocks   map[int64]*list.Element
+	lru      *list.List
+	inflight map[int64]*httpFetch
+}
+
+type httpBlock struct {
+	n    int64
+	data []byte
+}
+
+// httpFetch is a request of a range of blocks in progress, done is closed
+// once blocks or err are set.
+type httpFetch struct {
+	done   chan struct{}
+	blocks map[int64]*httpBlock
+	err    error
+}
+
+// NewHTTPReaderAt returns an HTTPReaderAt for the file at the given URL using
+// client to make the requests. The size of the file is requested on creation.
+func NewHTTPReaderAt(client *http.Client, url string) (*HTTPReaderAt, error) {
+	r := &HTTPReaderAt{
+		BlockSize:   DefaultHTTPBlockSize,
+		CacheBlocks: DefaultHTTPCacheBlocks,
+		client:      client,
+		url:         url,
+		blocks:      make(map[int64]*list.Element),
+		lru:         list.New(),
+		inflight:    make(map[int64]*httpFetch),
+	}
+
+	size, err := r.requestSize()
+	if err != nil {
+		return nil, err
+	}
+
+	r.size = size
+	return r, nil
+}
+
+// Size returns the size of the remote file.
+func (r *HTTPReaderAt) Size() int64 {
+	return r.size
+}
+
+func (r *HTTPReaderAt) requestSize() (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Range", "bytes=0-0")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return parseContentRangeSize(res.Header.Get("Content-Range"))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// only happens with empty files
+		return parseContentRangeSize(res.Header.Get("Content-Range"))
+	case http.StatusOK:
+		// some servers ignore the range of empty files
+		if res.ContentLength == 0 {
+			return 0, nil
+		}
+
+		return 0, ErrRangeNotSupported
+	default:
+		return 0, fmt.Errorf("unexpected HTTP status requesting %s: %s", r.url, res.Status)
+	}
+}
+
+// parseContentRangeSize returns the complete length of a Content-Range header
+// such as "bytes 0-0/1234" or "bytes */1234".
+func parseContentRangeSize(h string) (int64, error) {
+	slash := strings.LastIndexByte(h, '/')
+	if !strings.HasPrefix(h, "bytes ") || slash == -1 {
+		return 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+
+	size, err := strconv.ParseInt(h[slash+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+
+	return size, nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *HTTPReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if r.BlockSize <= 0 {
+		return 0, ErrInvalidBlockSize
+	}
+
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+
+	first, last := off/r.BlockSize, (end-1)/r.BlockSize
+	blocks, err := r.readBlocks(first, last)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, b := range blocks {
+		start := off + int64(n) - b.n*r.BlockSize
+		n += copy(p[n:], b.data[start:])
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// readBlocks returns the blocks in the range [first, last]. The blocks not
+// cached nor being requested by another read are requested with a single
+// request per contiguous range, the lock is not held while requesting them.
+func (r *HTTPReaderAt) readBlocks(first, last int64) ([]*httpBlock, error) {
+	blocks := make([]*httpBlock, last-first+1)
+	pending := make(map[int64]*httpFetch)
+	var own []*httpFetch
+	var ranges [][2]int64
+
+	r.mutex.Lock()
+	for n := first; n <= last; n++ {
+		if e, ok := r.blocks[n]; ok {
+			r.lru.MoveToFront(e)
+			blocks[n-first] = e.Value.(*httpBlock)
+			continue
+		}
+
+		if f, ok := r.inflight[n]; ok {
+			pending[n] = f
+			continue
+		}
+
+		if len(ranges) != 0 && ranges[len(ranges)-1][1] == n-1 {
+			ranges[len(ranges)-1][1] = n
+		} else {
+			own = append(own, &httpFetch{done: make(chan struct{})})
+			ranges = append(ranges, [2]int64{n, n})
+		}
+
+		f := own[len(own)-1]
+		r.inflight[n] = f
+		pending[n] = f
+	}
+	r.mutex.Unlock()
+
+	for j, f := range own {
+		r.complete(f, ranges[j][0], ranges[j][1])
+	}
+
+	for n, f := range pending {
+		<-f.done
+		if f.err != nil {
+			return nil, f.err
+		}
+
+		blocks[n-first] = f.blocks[n]
+	}
+
+	return blocks, nil
+}
+
+// complete requests the blocks of f, caching them, and marks it as done.
+func (r *HTTPReaderAt) complete(f *httpFetch, first, last int64) {
+	fetched, err := r.fetch(first, last)
+
+	r.mutex.Lock()
+	f.err = err
+	f.blocks = make(map[int64]*httpBlock, len(fetched))
+	for _, b := range fetched {
+		f.blocks[b.n] = b
+		r.cache(b)
+	}
+
+	for n := first; n <= last; n++ {
+		delete(r.inflight, n)
+	}
+	r.mutex.Unlock()
+
+	close(f.done)
+}
+
+func (r *HTTPReaderAt) fetch(first, last int64) ([]*httpBlock, error) {
+	start := first * r.BlockSize
+	end := (last+1)*r.BlockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected HTTP status requesting %s: %s", r.url, res.Status)
+	}
+
+	data := make([]byte, end-start+1)
+	if _, err := io.ReadFull(res.Body, data); err != nil {
+		return nil, err
+	}
+
+	var blocks []*httpBlock
+	for n := first; n <= last; n++ {
+		from := (n - first) * r.BlockSize
+		to := from + r.BlockSize
+		if to > int64(len(data)) {
+			to = int64(len(data))
+		}
+
+		blocks = append(blocks, &httpBlock{n: n, data: data[from:to:to]})
+	}
+
+	return blocks, nil
+}
+
+func (r *HTTPReaderAt) cache(b *httpBlock) {
+	if r.CacheBlocks <= 0 {
+		return
+	}
+
+	r.blocks[b.n] = r.lru.PushFront(b)
+	for r.lru.Len() > r.CacheBlocks {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		delete(r.blocks, e.Value.(*httpBlock).n)
+	}
+}
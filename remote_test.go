@@ -0,0 +1,209 @@
+package siva_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type RemoteSuite struct {
+	data     []byte
+	requests int32
+	server   *httptest.Server
+}
+
+var _ = Suite(&RemoteSuite{})
+
+func (s *RemoteSuite) SetUpTest(c *C) {
+	var err error
+	s.data, err = ioutil.ReadFile("fixtures/blocks.siva")
+	c.Assert(err, IsNil)
+
+	s.requests = 0
+	s.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&s.requests, 1)
+			http.ServeContent(w, r, "blocks.siva", time.Time{}, bytes.NewReader(s.data))
+		},
+	))
+}
+
+func (s *RemoteSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *RemoteSuite) TestOpenURL(c *C) {
+	r, err := siva.OpenURL(s.server.URL)
+	c.Assert(err, IsNil)
+
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+
+	e := i.Find("gopher.txt")
+	c.Assert(e, NotNil)
+
+	sr, err := r.Get(e)
+	c.Assert(err, IsNil)
+	content, err := ioutil.ReadAll(sr)
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "Gopher names:\nGeorge\nGeoffrey\nGonzo")
+
+	// the size and a single block with the whole file
+	c.Assert(atomic.LoadInt32(&s.requests), Equals, int32(2))
+}
+
+func (s *RemoteSuite) TestReadAt(c *C) {
+	ra, err := siva.NewHTTPReaderAt(http.DefaultClient, s.server.URL)
+	c.Assert(err, IsNil)
+	c.Assert(ra.Size(), Equals, int64(len(s.data)))
+
+	ra.BlockSize = 16
+	ra.CacheBlocks = 4
+
+	for _, t := range []struct{ off, len int }{
+		{0, 10}, {5, 30}, {100, 1}, {len(s.data) - 24, 24}, {0, len(s.data)},
+		{15, 2}, {len(s.data) - 5, 10},
+	} {
+		p := make([]byte, t.len)
+		n, err := ra.ReadAt(p, int64(t.off))
+
+		end := t.off + t.len
+		if end > len(s.data) {
+			end = len(s.data)
+			c.Assert(err, Equals, io.EOF)
+		} else {
+			c.Assert(err, IsNil)
+		}
+
+		c.Assert(p[:n], DeepEquals, s.data[t.off:end])
+	}
+
+	_, err = ra.ReadAt(make([]byte, 1), int64(len(s.data)))
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *RemoteSuite) TestCache(c *C) {
+	ra, err := siva.NewHTTPReaderAt(http.DefaultClient, s.server.URL)
+	c.Assert(err, IsNil)
+
+	ra.BlockSize = 16
+	ra.CacheBlocks = 2
+	p := make([]byte, 8)
+
+	requests := func() int32 { return atomic.LoadInt32(&s.requests) }
+	start := requests()
+
+	_, err = ra.ReadAt(p, 0)
+	c.Assert(err, IsNil)
+	_, err = ra.ReadAt(p, 8)
+	c.Assert(err, IsNil)
+	c.Assert(requests()-start, Equals, int32(1))
+
+	_, err = ra.ReadAt(p, 16)
+	c.Assert(err, IsNil)
+	_, err = ra.ReadAt(p, 32)
+	c.Assert(err, IsNil)
+	c.Assert(requests()-start, Equals, int32(3))
+
+	// the first block was evicted
+	_, err = ra.ReadAt(p, 0)
+	c.Assert(err, IsNil)
+	c.Assert(requests()-start, Equals, int32(4))
+}
+
+func (s *RemoteSuite) TestInvalidBlockSize(c *C) {
+	ra, err := siva.NewHTTPReaderAt(http.DefaultClient, s.server.URL)
+	c.Assert(err, IsNil)
+
+	ra.BlockSize = 0
+	_, err = ra.ReadAt(make([]byte, 8), 0)
+	c.Assert(err, Equals, siva.ErrInvalidBlockSize)
+}
+
+func (s *RemoteSuite) TestConcurrent(c *C) {
+	// block requests wait until two of them are in flight, so the reads of
+	// different blocks must not be serialized
+	var blocks int32
+	arrived := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "bytes=0-0" {
+				if atomic.AddInt32(&blocks, 1) == 2 {
+					once.Do(func() { close(arrived) })
+				}
+
+				select {
+				case <-arrived:
+				case <-time.After(5 * time.Second):
+					http.Error(w, "serialized requests", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			http.ServeContent(w, r, "blocks.siva", time.Time{}, bytes.NewReader(s.data))
+		},
+	))
+	defer server.Close()
+
+	ra, err := siva.NewHTTPReaderAt(http.DefaultClient, server.URL)
+	c.Assert(err, IsNil)
+	ra.BlockSize = 16
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for j := 0; j < 8; j++ {
+		wg.Add(1)
+		go func(off int64) {
+			defer wg.Done()
+			p := make([]byte, 8)
+			_, err := ra.ReadAt(p, off)
+			if err == nil && !bytes.Equal(p, s.data[off:off+8]) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			errs <- err
+		}(int64(j%2) * 16)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(atomic.LoadInt32(&blocks), Equals, int32(2))
+}
+
+func (s *RemoteSuite) TestRangeNotSupported(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write(s.data)
+		},
+	))
+	defer server.Close()
+
+	_, err := siva.OpenURL(server.URL)
+	c.Assert(err, Equals, siva.ErrRangeNotSupported)
+}
+
+func (s *RemoteSuite) TestEmpty(c *C) {
+	s.data = nil
+
+	r, err := siva.OpenURL(s.server.URL)
+	c.Assert(err, IsNil)
+
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 0)
+}
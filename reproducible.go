@@ -0,0 +1,67 @@
+package siva
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SourceDateEpoch returns the time defined by the SOURCE_DATE_EPOCH
+// environment variable, in seconds since the Unix epoch, or the Unix epoch if
+// it isn't defined. See https://reproducible-builds.org/specs/source-date-epoch/
+func SourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", v)
+	}
+
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// NormalizeHeader returns a copy of h suitable for reproducible archives, not
+// depending on the host where the archive is built. The modification time is
+// replaced by modTime and the permissions are normalized: 0755 for
+// directories and files executable by anyone, 0644 for the rest of the files
+// and 0777 for symlinks. The setuid, setgid and sticky bits are cleared.
+func NormalizeHeader(h *Header, modTime time.Time) *Header {
+	n := *h
+	n.ModTime = modTime
+	n.Mode = normalizeMode(h.Mode)
+	return &n
+}
+
+func normalizeMode(m os.FileMode) os.FileMode {
+	typ := m & os.ModeType
+	switch {
+	case m&os.ModeSymlink != 0:
+		return typ | 0777
+	case m.IsDir(), m&0111 != 0:
+		return typ | 0755
+	default:
+		return typ | 0644
+	}
+}
+
+type reproducibleWriter struct {
+	Writer
+	modTime time.Time
+}
+
+// NewReproducibleWriter returns a Writer writing to w the headers normalized
+// with NormalizeHeader. The entries are written in the given order, to build
+// the same archive from the same files they should be written sorted, for
+// example with a ParallelWriter.
+func NewReproducibleWriter(w Writer, modTime time.Time) Writer {
+	return &reproducibleWriter{Writer: w, modTime: modTime}
+}
+
+// WriteHeader writes the normalized version of h.
+func (w *reproducibleWriter) WriteHeader(h *Header) error {
+	return w.Writer.WriteHeader(NormalizeHeader(h, w.modTime))
+}
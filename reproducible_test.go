@@ -0,0 +1,68 @@
+package siva_test
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type ReproducibleSuite struct{}
+
+var _ = Suite(&ReproducibleSuite{})
+
+func (s *ReproducibleSuite) TestSourceDateEpoch(c *C) {
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	os.Unsetenv("SOURCE_DATE_EPOCH")
+	t, err := siva.SourceDateEpoch()
+	c.Assert(err, IsNil)
+	c.Assert(t.Unix(), Equals, int64(0))
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1500000000")
+	t, err = siva.SourceDateEpoch()
+	c.Assert(err, IsNil)
+	c.Assert(t.Equal(time.Unix(1500000000, 0)), Equals, true)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = siva.SourceDateEpoch()
+	c.Assert(err, ErrorMatches, `invalid SOURCE_DATE_EPOCH "yesterday"`)
+}
+
+func (s *ReproducibleSuite) TestNormalizeHeader(c *C) {
+	mtime := time.Unix(42, 0)
+	for mode, expected := range map[os.FileMode]os.FileMode{
+		0600:                              0644,
+		0664:                              0644,
+		0700:                              0755,
+		0711 | os.ModeSetuid:              0755,
+		os.ModeDir | 0700:                 os.ModeDir | 0755,
+		os.ModeDir | 0777 | os.ModeSticky: os.ModeDir | 0755,
+		os.ModeSymlink | 0700:             os.ModeSymlink | 0777,
+	} {
+		h := &siva.Header{Name: "foo", Mode: mode, ModTime: time.Now()}
+		n := siva.NormalizeHeader(h, mtime)
+		c.Assert(n.Mode, Equals, expected, Commentf("mode %s", mode))
+		c.Assert(n.ModTime, Equals, mtime)
+		c.Assert(n.Name, Equals, "foo")
+		c.Assert(h.Mode, Equals, mode)
+	}
+}
+
+func (s *ReproducibleSuite) TestWriter(c *C) {
+	build := func(mode os.FileMode, mtime time.Time) []byte {
+		buf := new(bytes.Buffer)
+		w := siva.NewReproducibleWriter(siva.NewWriter(buf), time.Unix(0, 0))
+		writeHeader(c, w, &siva.Header{Name: "foo", Mode: mode, ModTime: mtime}, "bar")
+		writeHeader(c, w, &siva.Header{Name: "qux", Mode: mode | 0111, ModTime: mtime}, "baz")
+		c.Assert(w.Close(), IsNil)
+		return buf.Bytes()
+	}
+
+	first := build(0600, time.Now())
+	second := build(0664, time.Now().Add(time.Hour))
+	c.Assert(second, DeepEquals, first)
+}
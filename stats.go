@@ -0,0 +1,107 @@
+package siva
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// StatsLargestEntries is the number of entries reported in
+// ArchiveStats.Largest.
+const StatsLargestEntries = 10
+
+// ArchiveStats describes the layout of a siva file, how much of it is taken
+// by entries not visible anymore is a hint of the benefit of compacting it.
+type ArchiveStats struct {
+	// Size is the size of the file.
+	Size int64
+	// Blocks is the number of blocks.
+	Blocks int
+	// Entries is the number of entries in all the indexes.
+	Entries int
+	// LiveEntries is the number of entries in the filtered index.
+	LiveEntries int
+	// ShadowedEntries is the number of entries overwritten or deleted by a
+	// later entry with the same name.
+	ShadowedEntries int
+	// DeletedEntries is the number of entries flagged as deleted.
+	DeletedEntries int
+	// LiveBytes is the size of the contents of the live entries.
+	LiveBytes uint64
+	// DeadBytes is the size of the contents of the rest of the entries.
+	DeadBytes uint64
+	// IndexBytes is the size of the indexes and footers of all the blocks.
+	IndexBytes uint64
+	// Largest are the largest live entries, sorted by size, up to
+	// StatsLargestEntries.
+	Largest []*IndexEntry
+	// Oldest and Newest are the minimum and maximum ModTime of the live
+	// entries, zero if there are none.
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Stats reads all the indexes of a siva file of the given size from ra and
+// returns its statistics.
+func Stats(ra io.ReaderAt, size int64) (*ArchiveStats, error) {
+	raw, err := ReadRawIndex(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	live := raw.Filter()
+	s := &ArchiveStats{
+		Size:        size,
+		Entries:     len(raw),
+		LiveEntries: len(live),
+	}
+
+	for _, e := range raw {
+		if e.Flags&FlagDeleted != 0 {
+			s.DeletedEntries++
+		}
+	}
+
+	s.ShadowedEntries = s.Entries - s.LiveEntries - s.DeletedEntries
+
+	for _, e := range live {
+		s.LiveBytes += e.Size
+		if s.Oldest.IsZero() || e.ModTime.Before(s.Oldest) {
+			s.Oldest = e.ModTime
+		}
+
+		if e.ModTime.After(s.Newest) {
+			s.Newest = e.ModTime
+		}
+	}
+
+	// the contents of a block go from its start to the end of its last
+	// entry, the rest of the block is its index
+	var contents uint64
+	blockEnds := make(map[uint64]uint64)
+	for _, e := range raw {
+		start, end := e.BlockOffset(), e.Start+e.Size
+		if cur, ok := blockEnds[start]; !ok || end > cur {
+			blockEnds[start] = end
+		}
+	}
+
+	for _, end := range blockEnds {
+		contents += end
+	}
+
+	s.Blocks = len(blockEnds)
+	s.DeadBytes = contents - s.LiveBytes
+	s.IndexBytes = uint64(size) - contents
+
+	s.Largest = append([]*IndexEntry(nil), live...)
+	sort.SliceStable(s.Largest, func(i, j int) bool {
+		return s.Largest[i].Size > s.Largest[j].Size
+	})
+
+	if len(s.Largest) > StatsLargestEntries {
+		s.Largest = s.Largest[:StatsLargestEntries]
+	}
+
+	return s, nil
+}
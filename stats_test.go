@@ -0,0 +1,70 @@
+package siva_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type StatsSuite struct{}
+
+var _ = Suite(&StatsSuite{})
+
+func (s *StatsSuite) TestStats(c *C) {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf).(siva.Committer)
+	writeHeader(c, w, &siva.Header{Name: "foo", ModTime: time.Unix(100, 0)}, "12345")
+	writeHeader(c, w, &siva.Header{Name: "bar", ModTime: time.Unix(200, 0)}, "123")
+	writeHeader(c, w, &siva.Header{Name: "qux", ModTime: time.Unix(300, 0)}, "1")
+	c.Assert(w.Commit(), IsNil)
+
+	writeHeader(c, w, &siva.Header{Name: "foo", ModTime: time.Unix(400, 0)}, "12")
+	writeHeader(c, w, &siva.Header{Name: "qux", Flags: siva.FlagDeleted}, "")
+	c.Assert(w.Close(), IsNil)
+
+	st, err := siva.Stats(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	c.Assert(err, IsNil)
+
+	c.Assert(st.Size, Equals, int64(buf.Len()))
+	c.Assert(st.Blocks, Equals, 2)
+	c.Assert(st.Entries, Equals, 5)
+	c.Assert(st.LiveEntries, Equals, 2)
+	c.Assert(st.ShadowedEntries, Equals, 2)
+	c.Assert(st.DeletedEntries, Equals, 1)
+	c.Assert(st.LiveBytes, Equals, uint64(5))
+	c.Assert(st.DeadBytes, Equals, uint64(6))
+	c.Assert(st.IndexBytes, Equals, uint64(buf.Len()-11))
+
+	c.Assert(st.Largest, HasLen, 2)
+	c.Assert(st.Largest[0].Name, Equals, "bar")
+	c.Assert(st.Largest[1].Name, Equals, "foo")
+	c.Assert(st.Oldest.Equal(time.Unix(200, 0)), Equals, true)
+	c.Assert(st.Newest.Equal(time.Unix(400, 0)), Equals, true)
+}
+
+func (s *StatsSuite) TestEmpty(c *C) {
+	st, err := siva.Stats(bytes.NewReader(nil), 0)
+	c.Assert(err, IsNil)
+	c.Assert(st, DeepEquals, &siva.ArchiveStats{})
+}
+
+func (s *StatsSuite) TestFixture(c *C) {
+	f, err := os.Open(filepath.Join("fixtures", "overwritten.siva"))
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	fi, err := f.Stat()
+	c.Assert(err, IsNil)
+
+	st, err := siva.Stats(f, fi.Size())
+	c.Assert(err, IsNil)
+	c.Assert(st.Blocks, Equals, 2)
+	c.Assert(st.LiveEntries, Equals, 3)
+	c.Assert(st.ShadowedEntries, Equals, 3)
+	c.Assert(st.LiveBytes+st.DeadBytes+st.IndexBytes, Equals, uint64(fi.Size()))
+}
@@ -0,0 +1,224 @@
+package siva
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotLocked = errors.New("storage is not locked")
+)
+
+// Storage is a backend holding the bytes of a siva file. Writes always
+// append to the end of the file. The lock is advisory, it only coordinates
+// the operations using the same storage, such as Append, Compact and Verify.
+type Storage interface {
+	io.ReaderAt
+	io.Writer
+	io.Closer
+	// Size returns the current size of the file.
+	Size() (int64, error)
+	// Truncate changes the size of the file, discarding the bytes after it.
+	Truncate(size int64) error
+	// Sync commits the written bytes to stable storage.
+	Sync() error
+	// Lock acquires a shared or exclusive lock, waiting up to timeout for it,
+	// or forever if timeout is zero. ErrLockTimeout is returned if the lock
+	// could not be acquired in time.
+	Lock(exclusive bool, timeout time.Duration) error
+	// Unlock releases the lock acquired with Lock.
+	Unlock() error
+}
+
+// OSStorage is a Storage backed by a file in the local filesystem, locked
+// with the same advisory locks used by OpenFile.
+type OSStorage struct {
+	f *os.File
+}
+
+// OpenOSStorage opens or creates the named file as a Storage.
+func OpenOSStorage(path string) (*OSStorage, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OSStorage{f: f}, nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *OSStorage) ReadAt(p []byte, off int64) (int, error) {
+	return s.f.ReadAt(p, off)
+}
+
+// Write appends p to the end of the file.
+func (s *OSStorage) Write(p []byte) (int, error) {
+	return s.f.Write(p)
+}
+
+// Size returns the current size of the file.
+func (s *OSStorage) Size() (int64, error) {
+	fi, err := s.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return fi.Size(), nil
+}
+
+// Truncate changes the size of the file.
+func (s *OSStorage) Truncate(size int64) error {
+	return s.f.Truncate(size)
+}
+
+// Sync commits the written bytes to disk.
+func (s *OSStorage) Sync() error {
+	return s.f.Sync()
+}
+
+// Lock acquires an advisory lock on the file.
+func (s *OSStorage) Lock(exclusive bool, timeout time.Duration) error {
+	return lockFile(s.f, exclusive, timeout)
+}
+
+// Unlock releases the advisory lock on the file.
+func (s *OSStorage) Unlock() error {
+	return unlock(s.f)
+}
+
+// Close closes the file, releasing any lock.
+func (s *OSStorage) Close() error {
+	return s.f.Close()
+}
+
+// MemoryStorage is a Storage keeping the file in memory, its lock only
+// coordinates the users of the same MemoryStorage. It is safe for concurrent
+// use.
+type MemoryStorage struct {
+	mutex sync.RWMutex
+	data  []byte
+
+	lockMutex sync.Mutex
+	readers   int
+	writer    bool
+}
+
+// NewMemoryStorage returns a MemoryStorage with the given initial content,
+// which is copied.
+func NewMemoryStorage(data []byte) *MemoryStorage {
+	return &MemoryStorage{data: append([]byte(nil), data...)}
+}
+
+// Bytes returns a copy of the content of the storage.
+func (s *MemoryStorage) Bytes() []byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]byte(nil), s.data...)
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *MemoryStorage) ReadAt(p []byte, off int64) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if off >= int64(len(s.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, s.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Write appends p to the end of the storage.
+func (s *MemoryStorage) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data = append(s.data, p...)
+	return len(p), nil
+}
+
+// Size returns the current size of the storage.
+func (s *MemoryStorage) Size() (int64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return int64(len(s.data)), nil
+}
+
+// Truncate changes the size of the storage, growing it with zeros if needed.
+func (s *MemoryStorage) Truncate(size int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if size < 0 {
+		return errors.New("negative size")
+	}
+
+	if size <= int64(len(s.data)) {
+		s.data = s.data[:size]
+		return nil
+	}
+
+	s.data = append(s.data, make([]byte, size-int64(len(s.data)))...)
+	return nil
+}
+
+// Sync does nothing.
+func (s *MemoryStorage) Sync() error {
+	return nil
+}
+
+// Lock acquires a shared or exclusive lock.
+func (s *MemoryStorage) Lock(exclusive bool, timeout time.Duration) error {
+	return retryLock(func() error {
+		s.lockMutex.Lock()
+		defer s.lockMutex.Unlock()
+
+		if s.writer || (exclusive && s.readers > 0) {
+			return errLocked
+		}
+
+		if exclusive {
+			s.writer = true
+		} else {
+			s.readers++
+		}
+
+		return nil
+	}, timeout)
+}
+
+// Unlock releases a lock acquired with Lock.
+func (s *MemoryStorage) Unlock() error {
+	s.lockMutex.Lock()
+	defer s.lockMutex.Unlock()
+
+	switch {
+	case s.writer:
+		s.writer = false
+	case s.readers > 0:
+		s.readers--
+	default:
+		return ErrNotLocked
+	}
+
+	return nil
+}
+
+// Close does nothing, the content is kept.
+func (s *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,212 @@
+package siva_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type StorageSuite struct {
+	newStorage func(c *C) siva.Storage
+}
+
+var _ = Suite(&StorageSuite{
+	newStorage: func(c *C) siva.Storage {
+		return siva.NewMemoryStorage(nil)
+	},
+})
+
+var _ = Suite(&StorageSuite{
+	newStorage: func(c *C) siva.Storage {
+		s, err := siva.OpenOSStorage(filepath.Join(c.MkDir(), "foo.siva"))
+		c.Assert(err, IsNil)
+		return s
+	},
+})
+
+func (s *StorageSuite) TestAppend(c *C) {
+	st := s.newStorage(c)
+	defer st.Close()
+
+	c.Assert(siva.Append(st, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "foo", "bar")
+		return nil
+	}), IsNil)
+
+	c.Assert(siva.Append(st, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "qux", "baz")
+		return nil
+	}), IsNil)
+
+	s.assertContent(c, st, map[string]string{"foo": "bar", "qux": "baz"})
+	c.Assert(siva.Verify(st, 0), IsNil)
+}
+
+func (s *StorageSuite) TestLockTimeout(c *C) {
+	st := siva.NewMemoryStorage(nil)
+	c.Assert(st.Lock(true, 0), IsNil)
+	defer st.Unlock()
+
+	err := siva.Append(st, 10*time.Millisecond, func(w siva.Writer) error {
+		c.Fatal("fn called without the lock")
+		return nil
+	})
+	c.Assert(err, Equals, siva.ErrLockTimeout)
+	c.Assert(siva.Verify(st, 10*time.Millisecond), Equals, siva.ErrLockTimeout)
+	c.Assert(siva.Compact(siva.NewMemoryStorage(nil), st, 10*time.Millisecond), Equals, siva.ErrLockTimeout)
+}
+
+func (s *StorageSuite) TestAppendError(c *C) {
+	st := s.newStorage(c)
+	defer st.Close()
+
+	c.Assert(siva.Append(st, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "foo", "bar")
+		return nil
+	}), IsNil)
+
+	size, err := st.Size()
+	c.Assert(err, IsNil)
+
+	failure := errors.New("failure")
+	err = siva.Append(st, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "qux", "baz")
+		return failure
+	})
+	c.Assert(err, Equals, failure)
+
+	newSize, err := st.Size()
+	c.Assert(err, IsNil)
+	c.Assert(newSize, Equals, size)
+	s.assertContent(c, st, map[string]string{"foo": "bar"})
+}
+
+func (s *StorageSuite) TestCompact(c *C) {
+	src := s.newStorage(c)
+	defer src.Close()
+
+	c.Assert(siva.Append(src, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "foo", "bar")
+		writeEntry(c, w, "qux", "baz")
+		writeEntry(c, w, "gone", "soon")
+		return nil
+	}), IsNil)
+
+	c.Assert(siva.Append(src, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "foo", "overwritten")
+		writeHeader(c, w, &siva.Header{Name: "gone", Flags: siva.FlagDeleted}, "")
+		return nil
+	}), IsNil)
+
+	dst := s.newStorage(c)
+	defer dst.Close()
+
+	c.Assert(siva.Compact(dst, src, 0), IsNil)
+	s.assertContent(c, dst, map[string]string{"foo": "overwritten", "qux": "baz"})
+
+	size, err := dst.Size()
+	c.Assert(err, IsNil)
+	i, err := siva.ReadRawIndex(dst, size)
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 2)
+	c.Assert(i.Blocks(), HasLen, 1)
+}
+
+func (s *StorageSuite) TestVerifyCorrupted(c *C) {
+	st := s.newStorage(c)
+	defer st.Close()
+
+	c.Assert(siva.Append(st, 0, func(w siva.Writer) error {
+		writeEntry(c, w, "foo", "bar")
+		writeEntry(c, w, "qux", "baz")
+		return nil
+	}), IsNil)
+
+	content := make([]byte, 6)
+	_, err := st.ReadAt(content, 0)
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "barbaz")
+
+	size, err := st.Size()
+	c.Assert(err, IsNil)
+	rest := make([]byte, size-4)
+	_, err = st.ReadAt(rest, 4)
+	c.Assert(err, IsNil)
+
+	c.Assert(st.Truncate(0), IsNil)
+	_, err = st.Write([]byte("barB"))
+	c.Assert(err, IsNil)
+	_, err = st.Write(rest)
+	c.Assert(err, IsNil)
+
+	err = siva.Verify(st, 0)
+	c.Assert(err, FitsTypeOf, &siva.EntryError{})
+	c.Assert(err.(*siva.EntryError).Name, Equals, "qux")
+	c.Assert(err.(*siva.EntryError).Err, Equals, siva.ErrInvalidCheckshum)
+}
+
+func (s *StorageSuite) TestLock(c *C) {
+	st := s.newStorage(c)
+	defer st.Close()
+
+	c.Assert(st.Lock(false, 0), IsNil)
+	c.Assert(st.Lock(false, 0), IsNil)
+	c.Assert(st.Unlock(), IsNil)
+	c.Assert(st.Unlock(), IsNil)
+
+	c.Assert(st.Lock(true, time.Millisecond), IsNil)
+	c.Assert(st.Unlock(), IsNil)
+}
+
+func (s *StorageSuite) assertContent(c *C, st siva.Storage, expected map[string]string) {
+	r, err := siva.NewStorageReader(st)
+	c.Assert(err, IsNil)
+
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	i = i.Filter()
+	c.Assert(i, HasLen, len(expected))
+
+	for name, content := range expected {
+		e := i.Find(name)
+		c.Assert(e, NotNil)
+
+		sr, err := r.Get(e)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, content)
+	}
+}
+
+type MemoryStorageSuite struct{}
+
+var _ = Suite(&MemoryStorageSuite{})
+
+func (s *MemoryStorageSuite) TestLockTimeout(c *C) {
+	st := siva.NewMemoryStorage(nil)
+
+	c.Assert(st.Lock(false, 0), IsNil)
+	c.Assert(st.Lock(true, 10*time.Millisecond), Equals, siva.ErrLockTimeout)
+	c.Assert(st.Unlock(), IsNil)
+
+	c.Assert(st.Lock(true, 0), IsNil)
+	c.Assert(st.Lock(false, 10*time.Millisecond), Equals, siva.ErrLockTimeout)
+	c.Assert(st.Unlock(), IsNil)
+	c.Assert(st.Unlock(), Equals, siva.ErrNotLocked)
+}
+
+func (s *MemoryStorageSuite) TestBytes(c *C) {
+	st := siva.NewMemoryStorage([]byte("foo"))
+	_, err := st.Write([]byte("bar"))
+	c.Assert(err, IsNil)
+	c.Assert(string(st.Bytes()), Equals, "foobar")
+
+	c.Assert(st.Truncate(2), IsNil)
+	c.Assert(string(st.Bytes()), Equals, "fo")
+}
@@ -0,0 +1,153 @@
+package siva
+
+import (
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sync writes to w the regular files in dir that are new or changed compared
+// with the index i, and a FlagDeleted entry for every entry of i under dir
+// whose file doesn't exist anymore. Entries are named as with ToSafePath over
+// the path of the file joined to dir, entries of i outside dir are ignored.
+//
+// A file is unchanged if its size and mode match the entry and either its
+// modification time or its CRC32 matches too, so files touched without
+// changing their content are not written again. If w is a *File, the file
+// itself is skipped when found in dir.
+//
+// The returned events describe the entries written, Entry is the entry of i
+// for modified and deleted names and nil for added ones.
+func Sync(w Writer, i Index, dir string) ([]Event, error) {
+	// i can share memory with the index of w, as with File.Index, so it is
+	// copied before writing anything
+	live := i.Filter()
+	current := make(map[string]*IndexEntry, len(live))
+	for _, e := range live {
+		current[e.Name] = e
+	}
+
+	var self os.FileInfo
+	if f, ok := w.(*File); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+
+		self = fi
+	}
+
+	var events []Event
+	seen := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() || (self != nil && os.SameFile(self, fi)) {
+			return nil
+		}
+
+		name := ToSafePath(path)
+		seen[name] = true
+
+		e := current[name]
+		if e != nil {
+			changed, err := isChanged(path, fi, e)
+			if err != nil || !changed {
+				return err
+			}
+		}
+
+		if err := syncFile(w, name, path, fi); err != nil {
+			return err
+		}
+
+		t := EventAdded
+		if e != nil {
+			t = EventModified
+		}
+
+		events = append(events, Event{Type: t, Name: name, Entry: e})
+		return nil
+	})
+
+	if err != nil {
+		return events, err
+	}
+
+	prefix := dirPrefix(ToSafePath(dir))
+	for _, e := range live {
+		if seen[e.Name] || !strings.HasPrefix(e.Name, prefix) {
+			continue
+		}
+
+		h := e.Header
+		h.Flags |= FlagDeleted
+		if err := w.WriteHeader(&h); err != nil {
+			return events, err
+		}
+
+		if err := w.Flush(); err != nil {
+			return events, err
+		}
+
+		events = append(events, Event{Type: EventDeleted, Name: e.Name, Entry: e})
+	}
+
+	return events, nil
+}
+
+func isChanged(path string, fi os.FileInfo, e *IndexEntry) (bool, error) {
+	if uint64(fi.Size()) != e.Size || fi.Mode() != e.Mode {
+		return true, nil
+	}
+
+	if fi.ModTime().Equal(e.ModTime) {
+		return false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+
+	return h.Sum32() != e.CRC32, nil
+}
+
+func syncFile(w Writer, name, path string, fi os.FileInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	err = w.WriteHeader(&Header{
+		Name:    name,
+		Mode:    fi.Mode(),
+		ModTime: fi.ModTime(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return err
+	}
+
+	if n != fi.Size() {
+		return &SizeMismatchError{Name: name, Expected: fi.Size(), Written: n}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,170 @@
+package siva_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type SyncSuite struct {
+	dir string
+	buf *bytes.Buffer
+}
+
+var _ = Suite(&SyncSuite{})
+
+func (s *SyncSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.buf = new(bytes.Buffer)
+
+	c.Assert(os.Mkdir(filepath.Join(s.dir, "sub"), 0755), IsNil)
+	s.writeFile(c, "foo", "foo")
+	s.writeFile(c, "sub/bar", "bar")
+}
+
+func (s *SyncSuite) writeFile(c *C, name, content string) {
+	path := filepath.Join(s.dir, name)
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0644), IsNil)
+
+	mtime := time.Unix(1500000000, 0)
+	c.Assert(os.Chtimes(path, mtime, mtime), IsNil)
+}
+
+func (s *SyncSuite) name(name string) string {
+	return siva.ToSafePath(filepath.Join(s.dir, name))
+}
+
+func (s *SyncSuite) sync(c *C) []siva.Event {
+	i := siva.Index{}
+	if s.buf.Len() > 0 {
+		var err error
+		i, err = siva.NewReader(bytes.NewReader(s.buf.Bytes())).Index()
+		c.Assert(err, IsNil)
+	}
+
+	w := siva.NewWriter(s.buf)
+	events, err := siva.Sync(w, i, s.dir)
+	c.Assert(err, IsNil)
+	c.Assert(w.Close(), IsNil)
+	return events
+}
+
+func (s *SyncSuite) assertEvents(c *C, events []siva.Event, expected map[string]siva.EventType) {
+	c.Assert(events, HasLen, len(expected))
+	for _, e := range events {
+		c.Assert(e.Type, Equals, expected[e.Name], Commentf("name %s", e.Name))
+	}
+}
+
+func (s *SyncSuite) TestSync(c *C) {
+	s.assertEvents(c, s.sync(c), map[string]siva.EventType{
+		s.name("foo"):     siva.EventAdded,
+		s.name("sub/bar"): siva.EventAdded,
+	})
+
+	size := s.buf.Len()
+	c.Assert(s.sync(c), HasLen, 0)
+	c.Assert(s.buf.Len(), Equals, size)
+
+	touched := time.Unix(1600000000, 0)
+	c.Assert(os.Chtimes(filepath.Join(s.dir, "foo"), touched, touched), IsNil)
+	c.Assert(s.sync(c), HasLen, 0)
+
+	s.writeFile(c, "sub/bar", "baz")
+	c.Assert(os.Chtimes(filepath.Join(s.dir, "sub/bar"), touched, touched), IsNil)
+	s.writeFile(c, "qux", "qux")
+	c.Assert(os.Remove(filepath.Join(s.dir, "foo")), IsNil)
+
+	s.assertEvents(c, s.sync(c), map[string]siva.EventType{
+		s.name("foo"):     siva.EventDeleted,
+		s.name("sub/bar"): siva.EventModified,
+		s.name("qux"):     siva.EventAdded,
+	})
+
+	r := siva.NewReader(bytes.NewReader(s.buf.Bytes()))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	i = i.Filter()
+	c.Assert(i, HasLen, 2)
+
+	e := i.Find(s.name("sub/bar"))
+	c.Assert(e, NotNil)
+	content, err := r.Get(e)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(content)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "baz")
+}
+
+func (s *SyncSuite) TestSyncIgnoresOtherEntries(c *C) {
+	w := siva.NewWriter(s.buf)
+	writeEntry(c, w, "outside/file", "content")
+	c.Assert(w.Close(), IsNil)
+
+	s.assertEvents(c, s.sync(c), map[string]siva.EventType{
+		s.name("foo"):     siva.EventAdded,
+		s.name("sub/bar"): siva.EventAdded,
+	})
+
+	i, err := siva.NewReader(bytes.NewReader(s.buf.Bytes())).Index()
+	c.Assert(err, IsNil)
+	c.Assert(i.Filter(), HasLen, 3)
+}
+
+func (s *SyncSuite) TestSyncFile(c *C) {
+	path := filepath.Join(s.dir, "archive.siva")
+	f, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+
+	events, err := siva.Sync(f, i, s.dir)
+	c.Assert(err, IsNil)
+	c.Assert(events, HasLen, 2)
+	c.Assert(f.Close(), IsNil)
+}
+
+func (s *SyncSuite) TestSyncFileAddAndDelete(c *C) {
+	for _, name := range []string{"a", "c", "d", "e", "z"} {
+		s.writeFile(c, name, name)
+	}
+
+	path := filepath.Join(c.MkDir(), "archive.siva")
+	f, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	_, err = siva.Sync(f, i, s.dir)
+	c.Assert(err, IsNil)
+	c.Assert(f.Commit(), IsNil)
+
+	s.writeFile(c, "b", "b")
+	c.Assert(os.Remove(filepath.Join(s.dir, "z")), IsNil)
+
+	i, err = f.Index()
+	c.Assert(err, IsNil)
+	events, err := siva.Sync(f, i, s.dir)
+	c.Assert(err, IsNil)
+	s.assertEvents(c, events, map[string]siva.EventType{
+		s.name("b"): siva.EventAdded,
+		s.name("z"): siva.EventDeleted,
+	})
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	i, err = f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i.Find(s.name("b")), NotNil)
+	c.Assert(i.Find(s.name("z")), IsNil)
+}
@@ -0,0 +1,164 @@
+package siva
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FromTar reads all the entries from a tar archive and writes them to w. The
+// regular files, directories and symbolic links are converted keeping their
+// name, mode and modification time, the target of a symbolic link is stored
+// as its content. Any other kind of entry, such as hard links or devices,
+// can't be represented and is skipped, as is the root directory, "./". The
+// writer is not closed.
+func FromTar(w Writer, r *tar.Reader) error {
+	for {
+		th, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := writeTarEntry(w, r, th); err != nil {
+			return err
+		}
+	}
+}
+
+func writeTarEntry(w Writer, r *tar.Reader, th *tar.Header) error {
+	var content io.Reader
+	switch th.Typeflag {
+	case tar.TypeReg, tar.TypeRegA:
+		content = r
+	case tar.TypeSymlink:
+		content = strings.NewReader(th.Linkname)
+	case tar.TypeDir:
+	default:
+		return nil
+	}
+
+	name := ToSafePath(th.Name)
+	if name == "" {
+		return nil
+	}
+
+	h := &Header{
+		Name:    name,
+		ModTime: th.ModTime,
+		Mode:    th.FileInfo().Mode(),
+	}
+
+	if err := w.WriteHeader(h); err != nil {
+		return err
+	}
+
+	if content != nil {
+		if _, err := io.Copy(w, content); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// ToTar writes the filtered index of r, and the content of its entries, to tw
+// sorted by name. The tar writer is not closed. See ToTarEntries.
+func ToTar(tw *tar.Writer, r Reader) error {
+	i, err := r.Index()
+	if err != nil {
+		return err
+	}
+
+	return ToTarEntries(tw, r, i)
+}
+
+// ToTarEntries writes the given entries of r to tw sorted by name, keeping
+// their mode and modification time. Directories and symbolic links, as
+// created by FromTar, are converted back to their tar counterparts.
+func ToTarEntries(tw *tar.Writer, r Reader, entries []*IndexEntry) error {
+	o := make(OrderedIndex, len(entries))
+	copy(o, entries)
+	sort.Stable(o)
+
+	for _, e := range o {
+		if err := writeTarFile(tw, r, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, r Reader, e *IndexEntry) error {
+	content, err := r.Get(e)
+	if err != nil {
+		return err
+	}
+
+	th := &tar.Header{
+		Name:    e.Name,
+		Mode:    tarMode(e.Mode),
+		ModTime: e.ModTime,
+	}
+
+	// by default the modification time is rounded to seconds
+	if e.ModTime.Nanosecond() != 0 {
+		th.Format = tar.FormatPAX
+	}
+
+	switch {
+	case e.Mode.IsDir():
+		th.Typeflag = tar.TypeDir
+		th.Name += "/"
+		content = nil
+	case e.Mode&os.ModeSymlink != 0:
+		target, err := ioutil.ReadAll(content)
+		if err != nil {
+			return err
+		}
+
+		th.Typeflag = tar.TypeSymlink
+		th.Linkname = string(target)
+		content = nil
+	default:
+		th.Typeflag = tar.TypeReg
+		th.Size = int64(e.Size)
+	}
+
+	if err := tw.WriteHeader(th); err != nil {
+		return err
+	}
+
+	if content == nil {
+		return nil
+	}
+
+	_, err = io.Copy(tw, content)
+	return err
+}
+
+// tarMode returns the tar mode bits of m, the permissions and the setuid,
+// setgid and sticky bits.
+func tarMode(m os.FileMode) int64 {
+	mode := int64(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		mode |= 04000
+	}
+
+	if m&os.ModeSetgid != 0 {
+		mode |= 02000
+	}
+
+	if m&os.ModeSticky != 0 {
+		mode |= 01000
+	}
+
+	return mode
+}
@@ -0,0 +1,160 @@
+package siva_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type TarSuite struct{}
+
+var _ = Suite(&TarSuite{})
+
+var tarTime = time.Date(2018, 5, 4, 3, 2, 1, 0, time.UTC)
+
+func (s *TarSuite) TestFromTar(c *C) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	s.writeTar(c, tw, &tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	s.writeTar(c, tw, &tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	s.writeTar(c, tw, &tar.Header{Name: "dir/foo", Typeflag: tar.TypeReg, Mode: 0640}, "foo")
+	s.writeTar(c, tw, &tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "foo", Mode: 0777}, "")
+	s.writeTar(c, tw, &tar.Header{Name: "dir/hard", Typeflag: tar.TypeLink, Linkname: "dir/foo", Mode: 0640}, "")
+	s.writeTar(c, tw, &tar.Header{Name: "run", Typeflag: tar.TypeReg, Mode: 04755}, "#!/bin/sh")
+	c.Assert(tw.Close(), IsNil)
+
+	out := new(bytes.Buffer)
+	w := siva.NewWriter(out)
+	c.Assert(siva.FromTar(w, tar.NewReader(buf)), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	r := siva.NewReader(bytes.NewReader(out.Bytes()))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 4)
+
+	expected := []struct {
+		name    string
+		mode    os.FileMode
+		content string
+	}{
+		{"dir", os.ModeDir | 0755, ""},
+		{"dir/foo", 0640, "foo"},
+		{"dir/link", os.ModeSymlink | 0777, "foo"},
+		{"run", os.ModeSetuid | 0755, "#!/bin/sh"},
+	}
+
+	for j, e := range i {
+		c.Assert(e.Name, Equals, expected[j].name)
+		c.Assert(e.Mode, Equals, expected[j].mode)
+		c.Assert(e.ModTime.Equal(tarTime), Equals, true)
+
+		sr, err := r.Get(e)
+		c.Assert(err, IsNil)
+		content, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+		c.Assert(string(content), Equals, expected[j].content)
+	}
+}
+
+func (s *TarSuite) TestToTar(c *C) {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	writeHeader(c, w, &siva.Header{Name: "zzz", Mode: 0600, ModTime: tarTime}, "zzz")
+	writeHeader(c, w, &siva.Header{Name: "dir", Mode: os.ModeDir | 0755, ModTime: tarTime}, "")
+	writeHeader(c, w, &siva.Header{Name: "dir/link", Mode: os.ModeSymlink | 0777, ModTime: tarTime}, "../zzz")
+	writeHeader(c, w, &siva.Header{Name: "run", Mode: os.ModeSetuid | 0755, ModTime: tarTime}, "#!/bin/sh")
+	writeHeader(c, w, &siva.Header{Name: "zzz", Flags: siva.FlagDeleted, ModTime: tarTime}, "")
+	writeHeader(c, w, &siva.Header{Name: "aaa", Mode: 0644, ModTime: tarTime}, "aaa")
+	c.Assert(w.Close(), IsNil)
+
+	out := new(bytes.Buffer)
+	tw := tar.NewWriter(out)
+	r := siva.NewReader(bytes.NewReader(buf.Bytes()))
+	c.Assert(siva.ToTar(tw, r), IsNil)
+	c.Assert(tw.Close(), IsNil)
+
+	expected := []struct {
+		name     string
+		typeflag byte
+		mode     int64
+		content  string
+		linkname string
+	}{
+		{"aaa", tar.TypeReg, 0644, "aaa", ""},
+		{"dir/", tar.TypeDir, 0755, "", ""},
+		{"dir/link", tar.TypeSymlink, 0777, "", "../zzz"},
+		{"run", tar.TypeReg, 04755, "#!/bin/sh", ""},
+	}
+
+	tr := tar.NewReader(out)
+	for _, e := range expected {
+		th, err := tr.Next()
+		c.Assert(err, IsNil)
+		c.Assert(th.Name, Equals, e.name)
+		c.Assert(th.Typeflag, Equals, e.typeflag)
+		c.Assert(th.Mode, Equals, e.mode)
+		c.Assert(th.Linkname, Equals, e.linkname)
+		c.Assert(th.ModTime.Equal(tarTime), Equals, true)
+
+		content, err := ioutil.ReadAll(tr)
+		c.Assert(err, IsNil)
+		c.Assert(string(content), Equals, e.content)
+	}
+
+	_, err := tr.Next()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *TarSuite) TestRoundTrip(c *C) {
+	f, err := os.Open("fixtures/perms.siva")
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	r := siva.NewReader(f)
+	c.Assert(siva.ToTar(tw, r), IsNil)
+	c.Assert(tw.Close(), IsNil)
+
+	out := new(bytes.Buffer)
+	w := siva.NewWriter(out)
+	c.Assert(siva.FromTar(w, tar.NewReader(buf)), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	expected, err := r.Index()
+	c.Assert(err, IsNil)
+
+	i, err := siva.NewReader(bytes.NewReader(out.Bytes())).Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, len(expected))
+
+	for j, e := range i {
+		c.Assert(e.Name, Equals, expected[j].Name)
+		c.Assert(e.Mode, Equals, expected[j].Mode)
+		c.Assert(e.Size, Equals, expected[j].Size)
+		c.Assert(e.CRC32, Equals, expected[j].CRC32)
+		c.Assert(e.ModTime.Equal(expected[j].ModTime), Equals, true)
+	}
+}
+
+func (s *TarSuite) TestFromTarInvalid(c *C) {
+	w := siva.NewWriter(new(bytes.Buffer))
+	err := siva.FromTar(w, tar.NewReader(bytes.NewReader(bytes.Repeat([]byte{1}, 1024))))
+	c.Assert(err, NotNil)
+}
+
+func (s *TarSuite) writeTar(c *C, tw *tar.Writer, h *tar.Header, content string) {
+	h.Size = int64(len(content))
+	h.ModTime = tarTime
+	c.Assert(tw.WriteHeader(h), IsNil)
+	_, err := tw.Write([]byte(content))
+	c.Assert(err, IsNil)
+}
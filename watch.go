@@ -0,0 +1,143 @@
+package siva
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshNotSupported = errors.New("reader doesn't support Refresh")
+)
+
+// EventType is the kind of change reported by Watch.
+type EventType int
+
+const (
+	// EventAdded is emitted when a name appears in the index.
+	EventAdded EventType = iota
+	// EventModified is emitted when a name is written again.
+	EventModified
+	// EventDeleted is emitted when a name disappears from the index.
+	EventDeleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventModified:
+		return "modified"
+	case EventDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change in the filtered index of a siva file. Entry is the
+// new entry for added and modified names and the last known entry for
+// deleted ones.
+type Event struct {
+	Type  EventType
+	Name  string
+	Entry *IndexEntry
+}
+
+// Watch calls Refresh on the reader every interval and emits an Event for each
+// name added, modified or deleted by the new blocks. The events channel is
+// closed when ctx is done or Refresh fails, in the latter case the error is
+// sent to the errors channel before closing it. ErrRefreshNotSupported is sent
+// if the reader is not a Refresher. The reader is refreshed from another
+// goroutine, so only Get should be called on it while watching.
+func Watch(ctx context.Context, r Reader, interval time.Duration) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		rf, ok := r.(Refresher)
+		if !ok {
+			errs <- ErrRefreshNotSupported
+			return
+		}
+
+		prev, err := r.Index()
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			changed, err := rf.Refresh()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if !changed {
+				continue
+			}
+
+			next, err := r.Index()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			for _, e := range diffIndex(prev, next) {
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			prev = next
+		}
+	}()
+
+	return events, errs
+}
+
+// diffIndex returns the events needed to go from the filtered index prev to
+// next, entries are considered modified when their content is in a different
+// position of the file.
+func diffIndex(prev, next Index) []Event {
+	before := make(map[string]*IndexEntry, len(prev))
+	for _, e := range prev {
+		before[e.Name] = e
+	}
+
+	var events []Event
+	for _, e := range next {
+		old, ok := before[e.Name]
+		delete(before, e.Name)
+
+		switch {
+		case !ok:
+			events = append(events, Event{EventAdded, e.Name, e})
+		case old.absStart != e.absStart:
+			events = append(events, Event{EventModified, e.Name, e})
+		}
+	}
+
+	for _, e := range prev {
+		if _, ok := before[e.Name]; ok {
+			events = append(events, Event{EventDeleted, e.Name, e})
+		}
+	}
+
+	return events
+}
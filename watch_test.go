@@ -0,0 +1,213 @@
+package siva_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type WatchSuite struct{}
+
+var _ = Suite(&WatchSuite{})
+
+func (s *WatchSuite) TestRefresh(c *C) {
+	path := filepath.Join(c.MkDir(), "refresh.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "foo")
+	writeEntry(c, w, "bar", "bar")
+	c.Assert(w.Close(), IsNil)
+
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	r := siva.NewReader(f).(siva.Refresher)
+	before, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(before, HasLen, 2)
+
+	changed, err := r.Refresh()
+	c.Assert(err, IsNil)
+	c.Assert(changed, Equals, false)
+
+	w, err = siva.OpenFile(path, os.O_RDWR)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "qux", "qux")
+	c.Assert(w.Close(), IsNil)
+
+	w, err = siva.OpenFile(path, os.O_RDWR)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "baz")
+	c.Assert(w.WriteHeader(&siva.Header{Name: "bar", Flags: siva.FlagDeleted}), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	changed, err = r.Refresh()
+	c.Assert(err, IsNil)
+	c.Assert(changed, Equals, true)
+
+	after, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(after, HasLen, 2)
+	c.Assert(after[0].Name, Equals, "foo")
+	c.Assert(after[1].Name, Equals, "qux")
+	c.Assert(before, HasLen, 2)
+	c.Assert(before[0].Name, Equals, "bar")
+
+	fresh, err := siva.NewReader(f).Index()
+	c.Assert(err, IsNil)
+	c.Assert(fresh, DeepEquals, after)
+}
+
+func (s *WatchSuite) TestRefreshTruncated(c *C) {
+	path := filepath.Join(c.MkDir(), "truncated.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "foo")
+	c.Assert(w.Close(), IsNil)
+
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	r := siva.NewReader(f).(siva.Refresher)
+	_, err = r.Index()
+	c.Assert(err, IsNil)
+
+	c.Assert(os.Truncate(path, 0), IsNil)
+
+	_, err = r.Refresh()
+	c.Assert(err, Equals, siva.ErrTruncated)
+}
+
+func (s *WatchSuite) TestRefreshPartialBlock(c *C) {
+	path := filepath.Join(c.MkDir(), "partial.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "foo")
+	c.Assert(w.Close(), IsNil)
+
+	block := new(bytes.Buffer)
+	bw := siva.NewWriter(block)
+	writeEntry(c, bw, "bar", "bar")
+	c.Assert(bw.Close(), IsNil)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	r := siva.NewReader(f).(siva.Refresher)
+	_, err = r.Index()
+	c.Assert(err, IsNil)
+
+	for _, part := range [][]byte{block.Bytes()[:3], block.Bytes()[3:40]} {
+		_, err = f.Write(part)
+		c.Assert(err, IsNil)
+
+		changed, err := r.Refresh()
+		c.Assert(err, IsNil)
+		c.Assert(changed, Equals, false)
+	}
+
+	_, err = f.Write(block.Bytes()[40:])
+	c.Assert(err, IsNil)
+
+	changed, err := r.Refresh()
+	c.Assert(err, IsNil)
+	c.Assert(changed, Equals, true)
+
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 2)
+}
+
+func (s *WatchSuite) TestWatchNotSupported(c *C) {
+	dir := c.MkDir()
+	f, err := siva.OpenFile(filepath.Join(dir, "file.siva"), os.O_CREATE|os.O_RDWR)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	tmp, err := os.Create(filepath.Join(dir, "readwriter.siva"))
+	c.Assert(err, IsNil)
+	defer tmp.Close()
+
+	rw, err := siva.NewReaderWriter(tmp)
+	c.Assert(err, IsNil)
+
+	readers := []siva.Reader{
+		struct{ siva.Reader }{siva.NewReader(bytes.NewReader(nil))},
+		siva.NewReaderWithOffset(bytes.NewReader(nil), 0),
+		siva.NewReaderAt(bytes.NewReader(nil), 0),
+		f,
+		rw,
+	}
+
+	for _, r := range readers {
+		events, errs := siva.Watch(context.Background(), r, time.Millisecond)
+		for range events {
+		}
+
+		c.Assert(<-errs, Equals, siva.ErrRefreshNotSupported, Commentf("reader %T", r))
+	}
+}
+
+func (s *WatchSuite) TestWatch(c *C) {
+	path := filepath.Join(c.MkDir(), "watch.siva")
+
+	w, err := siva.OpenFile(path, os.O_RDWR|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "foo")
+	writeEntry(c, w, "bar", "bar")
+	c.Assert(w.Close(), IsNil)
+
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	r := siva.NewReader(f)
+	_, err = r.Index()
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := siva.Watch(ctx, r, 10*time.Millisecond)
+
+	w, err = siva.OpenFile(path, os.O_RDWR)
+	c.Assert(err, IsNil)
+	writeEntry(c, w, "foo", "baz")
+	writeEntry(c, w, "qux", "qux")
+	c.Assert(w.WriteHeader(&siva.Header{Name: "bar", Flags: siva.FlagDeleted}), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	got := map[string]siva.EventType{}
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got[e.Name] = e.Type
+		case <-time.After(5 * time.Second):
+			c.Fatal("timeout waiting for events")
+		}
+	}
+
+	c.Assert(got, DeepEquals, map[string]siva.EventType{
+		"foo": siva.EventModified,
+		"qux": siva.EventAdded,
+		"bar": siva.EventDeleted,
+	})
+
+	cancel()
+	for range events {
+	}
+
+	c.Assert(<-errs, IsNil)
+}
@@ -3,11 +3,13 @@ package siva
 import (
 	"errors"
 	"io"
+	"time"
 )
 
 var (
-	ErrMissingHeader = errors.New("WriteHeader was not called, or already flushed")
-	ErrClosedWriter  = errors.New("Writer is closed")
+	ErrMissingHeader     = errors.New("WriteHeader was not called, or already flushed")
+	ErrClosedWriter      = errors.New("Writer is closed")
+	ErrAbortNotSupported = errors.New("abort not supported, the writer can't be truncated")
 )
 
 // A Writer provides sequential writing of a siva archive
@@ -18,13 +20,53 @@ type Writer interface {
 	Flush() error
 }
 
+// A Committer is a Writer able to finish blocks before being closed. The
+// writers returned by NewWriter and NewWriterWithOptions, ReadWriter and File
+// implement it.
+type Committer interface {
+	Writer
+	// Commit writes the index of the entries written since the last commit
+	// as a new block, the writer can keep being used after it.
+	Commit() error
+	// Abort discards the entries written since the last commit.
+	Abort() error
+}
+
+// WriterOptions configures the automatic sealing of blocks. When any of the
+// limits is reached after finishing an entry, the index of the current block
+// is written and a new block is started on the same stream, so the entries
+// written so far are readable without waiting for Close. Blocks are only
+// sealed on entry boundaries, by Flush or by the WriteHeader of the next entry,
+// never in the background. Zero values disable the corresponding limit.
+type WriterOptions struct {
+	// MaxEntries is the maximum number of entries in a block.
+	MaxEntries int
+	// MaxBytes is the size of the contents after which a block is sealed.
+	MaxBytes uint64
+	// Interval is the age of a block, since its first entry, after which it
+	// is sealed. It is checked only when an entry is finished, so the entries
+	// of an idle writer aren't readable until the next entry is finished or
+	// Commit is called. Commit can be called periodically to bound that delay.
+	Interval time.Duration
+}
+
 type writer struct {
 	w        *hashedWriter
+	sink     io.Writer
+	opts     WriterOptions
 	index    Index
-	oIndex   OrderedIndex
 	current  *IndexEntry
 	position uint64
+	started  time.Time
 	closed   bool
+
+	// oIndex holds every entry written, it is only kept for ReadWriter,
+	// along with the absolute offset of the current block and a copy of
+	// oIndex at the last commit, to restore it on Abort.
+	ordered   bool
+	oIndex    OrderedIndex
+	committed OrderedIndex
+	base      uint64
 }
 
 // NewWriter creates a new Writer writing to w.
@@ -32,9 +74,19 @@ func NewWriter(w io.Writer) Writer {
 	return newWriter(w)
 }
 
+// NewWriterWithOptions creates a new Writer writing to w, sealing blocks
+// automatically as configured by opts when entries are finished. If w has a
+// Sync method, it is called after every sealed block.
+func NewWriterWithOptions(w io.Writer, opts WriterOptions) Writer {
+	nw := newWriter(w)
+	nw.opts = opts
+	return nw
+}
+
 func newWriter(w io.Writer) *writer {
 	return &writer{
-		w: newHashedWriter(w),
+		w:    newHashedWriter(w),
+		sink: w,
 	}
 }
 
@@ -44,6 +96,10 @@ func (w *writer) WriteHeader(h *Header) error {
 		return err
 	}
 
+	if len(w.index) == 0 {
+		w.started = time.Now()
+	}
+
 	w.current = &IndexEntry{
 		Header: (*h),
 		Start:  w.position,
@@ -52,7 +108,9 @@ func (w *writer) WriteHeader(h *Header) error {
 	w.current.Name = ToSafePath(h.Name)
 
 	w.index = append(w.index, w.current)
-	w.oIndex = w.oIndex.Update(w.current)
+	if w.ordered {
+		w.oIndex = w.oIndex.Update(w.current)
+	}
 
 	return nil
 }
@@ -78,13 +136,45 @@ func (w *writer) Flush() error {
 		return ErrMissingHeader
 	}
 
+	return w.finish(w.w.Checksum())
+}
+
+// writeEntry writes an entry with the content of r, whose checksum is already
+// known, so it is copied to the underlying writer without hashing it again.
+func (w *writer) writeEntry(h *Header, r io.Reader, crc uint32) error {
+	if err := w.WriteHeader(h); err != nil {
+		return err
+	}
+
+	n, err := io.Copy(w.sink, r)
+	w.position += uint64(n)
+	if err != nil {
+		return err
+	}
+
+	return w.finish(crc)
+}
+
+func (w *writer) finish(crc uint32) error {
 	w.current.Size = w.position - w.current.Start
-	w.current.CRC32 = w.w.Checksum()
+	w.current.CRC32 = crc
 	w.current = nil
 	w.w.Reset()
+
+	if w.shouldSeal() {
+		return w.commit()
+	}
+
 	return nil
 }
 
+func (w *writer) shouldSeal() bool {
+	o := w.opts
+	return (o.MaxEntries > 0 && len(w.index) >= o.MaxEntries) ||
+		(o.MaxBytes > 0 && w.position >= o.MaxBytes) ||
+		(o.Interval > 0 && time.Since(w.started) >= o.Interval)
+}
+
 func (w *writer) flushIfPending() error {
 	if w.closed {
 		return ErrClosedWriter
@@ -94,6 +184,117 @@ func (w *writer) flushIfPending() error {
 	return w.Flush()
 }
 
+// Commit finishes the current entry and writes the index of the entries
+// written since the last commit, starting a new block. If the underlying
+// writer has a Sync method, it is called after writing the index.
+func (w *writer) Commit() error {
+	if err := w.flushIfPending(); err != nil {
+		return err
+	}
+
+	return w.commit()
+}
+
+func (w *writer) commit() error {
+	if len(w.index) == 0 {
+		return nil
+	}
+
+	if err := w.seal(); err != nil {
+		return err
+	}
+
+	if s, ok := w.sink.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+
+	return nil
+}
+
+// seal writes the index of the current block and starts a new one.
+func (w *writer) seal() error {
+	w.w.Reset()
+	if err := w.index.WriteTo(w.w); err != nil {
+		return err
+	}
+
+	for _, e := range w.index {
+		e.absStart = w.base + e.Start
+	}
+
+	w.base += w.position + uint64(w.w.Position())
+	w.index = nil
+	w.position = 0
+	w.w.Reset()
+
+	if w.ordered {
+		w.committed = append(OrderedIndex(nil), w.oIndex...)
+	}
+
+	return nil
+}
+
+// Abort discards the entries written since the last commit, including the
+// current one, removing their contents from the underlying writer. It must be
+// able to be truncated, having a Truncate method along with Seek or Size,
+// otherwise ErrAbortNotSupported is returned and nothing is discarded.
+func (w *writer) Abort() error {
+	if w.closed {
+		return ErrClosedWriter
+	}
+
+	if w.position > 0 {
+		if err := truncateTail(w.sink, w.position); err != nil {
+			return err
+		}
+	}
+
+	w.current = nil
+	w.index = nil
+	w.position = 0
+	w.w.Reset()
+
+	if w.ordered {
+		w.oIndex = append(OrderedIndex(nil), w.committed...)
+	}
+
+	return nil
+}
+
+// truncateTail removes the last n bytes written to w.
+func truncateTail(w io.Writer, n uint64) error {
+	switch t := w.(type) {
+	case interface {
+		Size() (int64, error)
+		Truncate(int64) error
+	}:
+		size, err := t.Size()
+		if err != nil {
+			return err
+		}
+
+		return t.Truncate(size - int64(n))
+	case interface {
+		io.Seeker
+		Truncate(int64) error
+	}:
+		size, err := t.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+
+		size -= int64(n)
+		if err := t.Truncate(size); err != nil {
+			return err
+		}
+
+		_, err = t.Seek(size, io.SeekStart)
+		return err
+	}
+
+	return ErrAbortNotSupported
+}
+
 // Close closes the siva archive, writing the Index footer to the current writer.
 func (w *writer) Close() error {
 	defer func() { w.closed = true }()
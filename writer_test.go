@@ -171,3 +171,167 @@ func (s *WriterSuite) TestWriterZeroLength(c *C) {
 	c.Assert(index[2].Name, Equals, "readme.txt")
 	c.Assert(index[3].Name, Equals, "some/path/file.txt")
 }
+
+func (s *WriterSuite) TestWriterMaxEntries(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriterWithOptions(buf, WriterOptions{MaxEntries: 2})
+	for _, file := range files {
+		s.writeFixture(c, w, file)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 2)
+}
+
+func (s *WriterSuite) TestWriterMaxBytes(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriterWithOptions(buf, WriterOptions{MaxBytes: 1})
+	for _, file := range files {
+		s.writeFixture(c, w, file)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 3)
+}
+
+func (s *WriterSuite) TestWriterInterval(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriterWithOptions(buf, WriterOptions{Interval: time.Hour})
+	for _, file := range files {
+		s.writeFixture(c, w, file)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 1)
+
+	buf.Reset()
+	w = NewWriterWithOptions(buf, WriterOptions{Interval: time.Nanosecond})
+	for _, file := range files {
+		s.writeFixture(c, w, file)
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 3)
+}
+
+func (s *WriterSuite) TestWriterIntervalIdle(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriterWithOptions(buf, WriterOptions{Interval: 50 * time.Millisecond})
+	c.Assert(w.WriteHeader(&Header{Name: "foo"}), IsNil)
+	_, err := w.Write([]byte("foo"))
+	c.Assert(err, IsNil)
+	c.Assert(w.Flush(), IsNil)
+
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(buf.Len(), Equals, 3)
+
+	c.Assert(w.WriteHeader(&Header{Name: "bar"}), IsNil)
+	c.Assert(w.Flush(), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	raw, err := ReadRawIndex(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	c.Assert(err, IsNil)
+	c.Assert(raw, HasLen, 2)
+	c.Assert(raw.Blocks(), HasLen, 1)
+}
+
+func (s *WriterSuite) TestWriterSealedBlockReadable(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriterWithOptions(buf, WriterOptions{MaxEntries: 1})
+	s.writeFixture(c, w, files[0])
+	c.Assert(w.Flush(), IsNil)
+	sealed := buf.Len()
+
+	s.writeFixture(c, w, files[1])
+
+	r := NewReaderWithOffset(bytes.NewReader(buf.Bytes()), uint64(sealed))
+	index, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(index, HasLen, 1)
+	c.Assert(index[0].Name, Equals, files[0].Name)
+
+	c.Assert(w.Close(), IsNil)
+	c.Assert(w.(*writer).index, HasLen, 0)
+
+	r = NewReader(bytes.NewReader(buf.Bytes()))
+	index, err = r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(index, HasLen, 2)
+}
+
+func (s *WriterSuite) assertBlocks(c *C, data []byte, blocks int) {
+	r := NewReader(bytes.NewReader(data))
+	index, err := r.Index()
+	c.Assert(err, IsNil)
+	s.assertIndex(c, r, index)
+
+	raw, err := ReadRawIndex(bytes.NewReader(data), int64(len(data)))
+	c.Assert(err, IsNil)
+	c.Assert(raw.Blocks(), HasLen, blocks)
+}
+
+func (s *WriterSuite) TestWriterCommit(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf).(Committer)
+	s.writeFixture(c, w, files[0])
+	c.Assert(w.Commit(), IsNil)
+	committed := buf.Len()
+
+	c.Assert(w.Commit(), IsNil)
+	c.Assert(buf.Len(), Equals, committed)
+
+	for _, file := range files[1:] {
+		s.writeFixture(c, w, file)
+	}
+
+	c.Assert(w.Commit(), IsNil)
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 2)
+}
+
+func (s *WriterSuite) TestWriterAbortNotSupported(c *C) {
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf).(Committer)
+	s.writeFixture(c, w, files[0])
+	c.Assert(w.Commit(), IsNil)
+
+	s.writeFixture(c, w, files[1])
+	c.Assert(w.Flush(), IsNil)
+	c.Assert(w.Abort(), Equals, ErrAbortNotSupported)
+
+	s.writeFixture(c, w, files[2])
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, buf.Bytes(), 2)
+}
+
+func (s *WriterSuite) TestWriterAbortTruncate(c *C) {
+	st := NewMemoryStorage(nil)
+	w := NewWriter(st).(Committer)
+	s.writeFixture(c, w, files[0])
+	c.Assert(w.Commit(), IsNil)
+	committed, err := st.Size()
+	c.Assert(err, IsNil)
+
+	s.writeFixture(c, w, fileFixture{"aborted.txt", "discarded content"})
+	c.Assert(w.Abort(), IsNil)
+
+	size, err := st.Size()
+	c.Assert(err, IsNil)
+	c.Assert(size, Equals, committed)
+
+	for _, file := range files[1:] {
+		s.writeFixture(c, w, file)
+	}
+
+	c.Assert(w.Close(), IsNil)
+	s.assertBlocks(c, st.Bytes(), 2)
+	c.Assert(bytes.Contains(st.Bytes(), []byte("discarded content")), Equals, false)
+}
+
+func (s *WriterSuite) TestWriterAbortClosed(c *C) {
+	w := NewWriter(new(bytes.Buffer)).(Committer)
+	c.Assert(w.Close(), IsNil)
+	c.Assert(w.Abort(), Equals, ErrClosedWriter)
+	c.Assert(w.Commit(), Equals, ErrClosedWriter)
+}
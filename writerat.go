@@ -0,0 +1,228 @@
+package siva
+
+import (
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+// SizeMismatchError is returned when the content written to an entry of a
+// WriterAt doesn't match the size given to WriteHeader.
+type SizeMismatchError struct {
+	Name     string
+	Expected int64
+	Written  int64
+}
+
+func (e *SizeMismatchError) Error() string {
+	if e.Written > e.Expected {
+		return fmt.Sprintf("%s: expected %d bytes, got more", e.Name, e.Expected)
+	}
+
+	return fmt.Sprintf("%s: expected %d bytes, got %d", e.Name, e.Expected, e.Written)
+}
+
+// WriterAt writes a block of a siva file to an io.WriterAt. The size of every
+// entry is given in WriteHeader, reserving its range in the block, so the
+// contents of the entries can be written concurrently and in any order. The
+// index is written on Close, in the order the headers were written.
+type WriterAt struct {
+	w      io.WriterAt
+	offset int64
+	// onClose is called once the WriterAt is closed or aborted, rollback
+	// is true if no index was written.
+	onClose func(rollback bool) error
+
+	mutex    sync.Mutex
+	entries  []*entryWriterAt
+	position uint64
+	closed   bool
+}
+
+// NewWriterAt creates a new WriterAt writing a block starting at offset, to
+// append it to an existing siva file offset should be its size.
+func NewWriterAt(w io.WriterAt, offset int64) *WriterAt {
+	return &WriterAt{w: w, offset: offset}
+}
+
+// WriteHeader reserves size bytes for a new entry with the given header and
+// returns the writer of its content, which must be closed once the content is
+// written. Close returns a *SizeMismatchError if the size of the content
+// doesn't match. WriteHeader is safe for concurrent use, every returned writer
+// can be used from any goroutine.
+func (w *WriterAt) WriteHeader(h *Header, size int64) (io.WriteCloser, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil, ErrClosedWriter
+	}
+
+	e := &entryWriterAt{
+		entry: &IndexEntry{
+			Header: *h,
+			Start:  w.position,
+			Size:   uint64(size),
+		},
+		w:     w.w,
+		start: w.offset + int64(w.position),
+		crc:   crc32.NewIEEE(),
+	}
+
+	e.entry.Name = ToSafePath(h.Name)
+	w.entries = append(w.entries, e)
+	w.position += uint64(size)
+	return e, nil
+}
+
+// Close writes the index of the block. All the entry writers must have been
+// closed successfully, otherwise the error of the first failed entry, or
+// ErrEntryNotClosed, is returned and no index is written. When the WriterAt
+// was returned by File.WriterAt, the file is truncated back to its previous
+// size if Close fails.
+func (w *WriterAt) Close() error {
+	return w.close(true)
+}
+
+// Abort discards the block without writing its index. When the WriterAt was
+// returned by File.WriterAt, the file is truncated back to its previous size.
+// The entry writers must not be used during or after Abort.
+func (w *WriterAt) Abort() error {
+	return w.close(false)
+}
+
+func (w *WriterAt) close(commit bool) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrClosedWriter
+	}
+
+	w.closed = true
+
+	var err error
+	if commit {
+		err = w.writeIndex()
+	}
+
+	if w.onClose == nil {
+		return err
+	}
+
+	if cerr := w.onClose(!commit || err != nil); cerr != nil {
+		if err == nil {
+			return cerr
+		}
+
+		return fmt.Errorf("%s, and %s", err, cerr)
+	}
+
+	return err
+}
+
+func (w *WriterAt) writeIndex() error {
+	index := make(Index, 0, len(w.entries))
+	for _, e := range w.entries {
+		entry, err := e.result()
+		if err != nil {
+			return err
+		}
+
+		index = append(index, entry)
+	}
+
+	ow := io.NewOffsetWriter(w.w, w.offset+int64(w.position))
+	err := index.WriteTo(ow)
+	if err == ErrEmptyIndex {
+		return nil
+	}
+
+	return err
+}
+
+type entryWriterAt struct {
+	entry *IndexEntry
+	w     io.WriterAt
+	start int64
+
+	mutex   sync.Mutex
+	written int64
+	crc     hash.Hash32
+	closed  bool
+	err     error
+}
+
+// Write writes p after the content already written to the entry.
+func (e *entryWriterAt) Write(p []byte) (int, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return 0, ErrClosedWriter
+	} else if e.err != nil {
+		return 0, e.err
+	}
+
+	size := int64(e.entry.Size)
+	if e.written+int64(len(p)) > size {
+		e.err = &SizeMismatchError{
+			Name:     e.entry.Name,
+			Expected: size,
+			Written:  e.written + int64(len(p)),
+		}
+
+		return 0, e.err
+	}
+
+	n, err := e.w.WriteAt(p, e.start+e.written)
+	e.crc.Write(p[:n])
+	e.written += int64(n)
+	if err != nil {
+		e.err = err
+	}
+
+	return n, err
+}
+
+// Close finishes the entry, checking that its whole content was written.
+func (e *entryWriterAt) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return ErrClosedWriter
+	}
+
+	e.closed = true
+	if e.err == nil && e.written != int64(e.entry.Size) {
+		e.err = &SizeMismatchError{
+			Name:     e.entry.Name,
+			Expected: int64(e.entry.Size),
+			Written:  e.written,
+		}
+	}
+
+	if e.err == nil {
+		e.entry.CRC32 = e.crc.Sum32()
+	}
+
+	return e.err
+}
+
+func (e *entryWriterAt) result() (*IndexEntry, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.closed {
+		return nil, ErrEntryNotClosed
+	}
+
+	return e.entry, e.err
+}
@@ -0,0 +1,227 @@
+package siva_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type WriterAtSuite struct{}
+
+var _ = Suite(&WriterAtSuite{})
+
+type writerAtBuffer struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (b *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if end := int(off) + len(p); end > len(b.data) {
+		b.data = append(b.data, make([]byte, end-len(b.data))...)
+	}
+
+	return copy(b.data[off:], p), nil
+}
+
+func (s *WriterAtSuite) TestConcurrent(c *C) {
+	buf := &writerAtBuffer{}
+	w := siva.NewWriterAt(buf, 0)
+
+	var writers []io.WriteCloser
+	for n := 0; n < 20; n++ {
+		ew, err := w.WriteHeader(&siva.Header{Name: fmt.Sprintf("file-%02d", n)}, int64(n*3))
+		c.Assert(err, IsNil)
+		writers = append(writers, ew)
+	}
+
+	var wg sync.WaitGroup
+	for n := len(writers) - 1; n >= 0; n-- {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				_, err := writers[n].Write([]byte(fmt.Sprintf("%03d", n)))
+				c.Check(err, IsNil)
+			}
+
+			c.Check(writers[n].Close(), IsNil)
+		}(n)
+	}
+
+	wg.Wait()
+	c.Assert(w.Close(), IsNil)
+
+	r := siva.NewReader(bytes.NewReader(buf.data))
+	i, err := r.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 20)
+
+	for n, e := range i {
+		c.Assert(e.Name, Equals, fmt.Sprintf("file-%02d", n))
+
+		content, err := r.Get(e)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(content)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, strings.Repeat(fmt.Sprintf("%03d", n), n))
+	}
+}
+
+func (s *WriterAtSuite) TestSameAsWriter(c *C) {
+	expected := new(bytes.Buffer)
+	w := siva.NewWriter(expected)
+	writeEntry(c, w, "foo", "bar")
+	writeEntry(c, w, "qux", "")
+	c.Assert(w.Close(), IsNil)
+
+	buf := &writerAtBuffer{}
+	wa := siva.NewWriterAt(buf, 0)
+	foo, err := wa.WriteHeader(&siva.Header{Name: "foo"}, 3)
+	c.Assert(err, IsNil)
+	qux, err := wa.WriteHeader(&siva.Header{Name: "qux"}, 0)
+	c.Assert(err, IsNil)
+
+	c.Assert(qux.Close(), IsNil)
+	_, err = foo.Write([]byte("bar"))
+	c.Assert(err, IsNil)
+	c.Assert(foo.Close(), IsNil)
+	c.Assert(wa.Close(), IsNil)
+
+	c.Assert(buf.data, DeepEquals, expected.Bytes())
+}
+
+func (s *WriterAtSuite) TestSizeMismatch(c *C) {
+	w := siva.NewWriterAt(&writerAtBuffer{}, 0)
+
+	short, err := w.WriteHeader(&siva.Header{Name: "short"}, 4)
+	c.Assert(err, IsNil)
+	_, err = short.Write([]byte("foo"))
+	c.Assert(err, IsNil)
+	err = short.Close()
+	c.Assert(err, DeepEquals, &siva.SizeMismatchError{Name: "short", Expected: 4, Written: 3})
+	c.Assert(err, ErrorMatches, "short: expected 4 bytes, got 3")
+
+	long, err := w.WriteHeader(&siva.Header{Name: "long"}, 2)
+	c.Assert(err, IsNil)
+	_, err = long.Write([]byte("foo"))
+	c.Assert(err, ErrorMatches, "long: expected 2 bytes, got more")
+	c.Assert(long.Close(), ErrorMatches, "long: expected 2 bytes, got more")
+
+	c.Assert(w.Close(), ErrorMatches, "short: expected 4 bytes, got 3")
+}
+
+func (s *WriterAtSuite) TestEntryNotClosed(c *C) {
+	buf := &writerAtBuffer{}
+	w := siva.NewWriterAt(buf, 0)
+
+	_, err := w.WriteHeader(&siva.Header{Name: "foo"}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(w.Close(), Equals, siva.ErrEntryNotClosed)
+	c.Assert(buf.data, HasLen, 0)
+
+	_, err = w.WriteHeader(&siva.Header{Name: "foo"}, 0)
+	c.Assert(err, Equals, siva.ErrClosedWriter)
+}
+
+func (s *WriterAtSuite) TestFile(c *C) {
+	path := filepath.Join(c.MkDir(), "foo.siva")
+
+	f, err := siva.OpenFile(path, os.O_WRONLY|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "foo", "bar")
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_RDWR)
+	c.Assert(err, IsNil)
+
+	w, err := f.WriterAt()
+	c.Assert(err, IsNil)
+	ew, err := w.WriteHeader(&siva.Header{Name: "qux"}, 3)
+	c.Assert(err, IsNil)
+	_, err = ew.Write([]byte("baz"))
+	c.Assert(err, IsNil)
+	c.Assert(ew.Close(), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	writeEntry(c, f, "last", "entry")
+
+	i, err := f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+	c.Assert(f.Close(), IsNil)
+
+	f, err = siva.OpenFile(path, os.O_RDONLY)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	_, err = f.WriterAt()
+	c.Assert(err, Equals, siva.ErrReadOnlyFile)
+
+	i, err = f.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 3)
+
+	for name, expected := range map[string]string{"foo": "bar", "qux": "baz", "last": "entry"} {
+		content, err := f.Get(i.Find(name))
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(content)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, expected)
+	}
+}
+
+func (s *WriterAtSuite) TestFileRollback(c *C) {
+	path := filepath.Join(c.MkDir(), "foo.siva")
+
+	f, err := siva.OpenFile(path, os.O_WRONLY|os.O_CREATE)
+	c.Assert(err, IsNil)
+	writeEntry(c, f, "foo", "bar")
+	c.Assert(f.Close(), IsNil)
+
+	fi, err := os.Stat(path)
+	c.Assert(err, IsNil)
+
+	for _, abort := range []bool{false, true} {
+		f, err = siva.OpenFile(path, os.O_RDWR)
+		c.Assert(err, IsNil)
+
+		w, err := f.WriterAt()
+		c.Assert(err, IsNil)
+		ew, err := w.WriteHeader(&siva.Header{Name: "qux"}, 10)
+		c.Assert(err, IsNil)
+		_, err = ew.Write([]byte("baz"))
+		c.Assert(err, IsNil)
+
+		if abort {
+			c.Assert(w.Abort(), IsNil)
+		} else {
+			c.Assert(ew.Close(), FitsTypeOf, &siva.SizeMismatchError{})
+			c.Assert(w.Close(), FitsTypeOf, &siva.SizeMismatchError{})
+		}
+
+		c.Assert(f.Close(), IsNil)
+
+		size, err := os.Stat(path)
+		c.Assert(err, IsNil)
+		c.Assert(size.Size(), Equals, fi.Size())
+
+		f, err = siva.OpenFile(path, os.O_RDONLY)
+		c.Assert(err, IsNil)
+		i, err := f.Index()
+		c.Assert(err, IsNil)
+		c.Assert(i, HasLen, 1)
+		c.Assert(f.Close(), IsNil)
+	}
+}
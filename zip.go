@@ -0,0 +1,125 @@
+package siva
+
+import (
+	"archive/zip"
+	"io"
+	"sort"
+)
+
+// FromZip reads all the files from a zip archive and writes them to w,
+// keeping their name, mode and modification time. The content of each file
+// is verified against the CRC32 stored in the zip archive while it's copied,
+// since both formats use IEEE CRC32 the checksum stays the same. The writer
+// is not closed.
+func FromZip(w Writer, r *zip.Reader) error {
+	for _, f := range r.File {
+		if err := writeZipFile(w, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeZipFile(w Writer, f *zip.File) error {
+	name := ToSafePath(f.Name)
+	if name == "" {
+		return nil
+	}
+
+	h := &Header{
+		Name:    name,
+		ModTime: f.Modified,
+		Mode:    f.Mode(),
+	}
+
+	if err := w.WriteHeader(h); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	hr := newHashedReader(rc)
+	if _, err := io.Copy(w, hr); err != nil {
+		return err
+	}
+
+	if hr.Checkshum() != f.CRC32 {
+		return ErrInvalidCheckshum
+	}
+
+	return w.Flush()
+}
+
+// ToZip writes the filtered index of r, and the content of its entries, to zw
+// sorted by name. The zip writer is not closed. See ToZipEntries.
+func ToZip(zw *zip.Writer, r Reader) error {
+	i, err := r.Index()
+	if err != nil {
+		return err
+	}
+
+	return ToZipEntries(zw, r, i)
+}
+
+// ToZipEntries writes the given entries of r to zw sorted by name, keeping
+// their mode and modification time. The content of each entry is verified
+// against its CRC32 while it's copied. Large files are stored using zip64
+// automatically.
+func ToZipEntries(zw *zip.Writer, r Reader, entries []*IndexEntry) error {
+	o := make(OrderedIndex, len(entries))
+	copy(o, entries)
+	sort.Stable(o)
+
+	for _, e := range o {
+		if err := writeZipEntry(zw, r, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeZipEntry(zw *zip.Writer, r Reader, e *IndexEntry) error {
+	zh := &zip.FileHeader{
+		Name:     e.Name,
+		Method:   zip.Deflate,
+		Modified: e.ModTime,
+	}
+
+	zh.SetMode(e.Mode)
+	if e.Mode.IsDir() {
+		zh.Name += "/"
+		zh.Method = zip.Store
+	}
+
+	content, err := r.Get(e)
+	if err != nil {
+		return err
+	}
+
+	w, err := zw.CreateHeader(zh)
+	if err != nil {
+		return err
+	}
+
+	if e.Mode.IsDir() {
+		return nil
+	}
+
+	hw := newHashedWriter(w)
+	if _, err := io.Copy(hw, content); err != nil {
+		return err
+	}
+
+	if hw.Checksum() != e.CRC32 {
+		return ErrInvalidCheckshum
+	}
+
+	return nil
+}
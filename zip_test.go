@@ -0,0 +1,174 @@
+package siva_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gopkg.in/src-d/go-siva.v1"
+
+	. "gopkg.in/check.v1"
+)
+
+type ZipSuite struct{}
+
+var _ = Suite(&ZipSuite{})
+
+func (s *ZipSuite) TestRoundTrip(c *C) {
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	writeHeader(c, w, &siva.Header{Name: "zzz", Mode: 0600, ModTime: tarTime}, "zzz")
+	writeHeader(c, w, &siva.Header{Name: "dir", Mode: os.ModeDir | 0755, ModTime: tarTime}, "")
+	writeHeader(c, w, &siva.Header{Name: "dir/link", Mode: os.ModeSymlink | 0777, ModTime: tarTime}, "../zzz")
+	writeHeader(c, w, &siva.Header{Name: "run", Mode: os.ModeSetuid | 0755, ModTime: tarTime}, "#!/bin/sh")
+	writeHeader(c, w, &siva.Header{Name: "empty", Mode: 0644, ModTime: tarTime}, "")
+	c.Assert(w.Close(), IsNil)
+
+	r := siva.NewReader(bytes.NewReader(buf.Bytes()))
+	expected, err := r.Index()
+	c.Assert(err, IsNil)
+
+	zbuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zbuf)
+	c.Assert(siva.ToZip(zw, r), IsNil)
+	c.Assert(zw.Close(), IsNil)
+
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	c.Assert(err, IsNil)
+	c.Assert(zr.File, HasLen, 5)
+	c.Assert(zr.File[0].Name, Equals, "dir/")
+
+	out := new(bytes.Buffer)
+	w = siva.NewWriter(out)
+	c.Assert(siva.FromZip(w, zr), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	converted := siva.NewReader(bytes.NewReader(out.Bytes()))
+	i, err := converted.Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, len(expected))
+
+	for j, e := range i {
+		c.Assert(e.Name, Equals, expected[j].Name)
+		c.Assert(e.Mode, Equals, expected[j].Mode)
+		c.Assert(e.Size, Equals, expected[j].Size)
+		c.Assert(e.CRC32, Equals, expected[j].CRC32)
+		c.Assert(e.ModTime.Equal(expected[j].ModTime), Equals, true)
+		c.Assert(zr.File[j].CRC32, Equals, e.CRC32)
+
+		sr, err := converted.Get(e)
+		c.Assert(err, IsNil)
+		content, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+
+		sr, err = r.Get(expected[j])
+		c.Assert(err, IsNil)
+		expectedContent, err := ioutil.ReadAll(sr)
+		c.Assert(err, IsNil)
+
+		c.Assert(content, DeepEquals, expectedContent)
+	}
+}
+
+func (s *ZipSuite) TestFromZipRoot(c *C) {
+	zbuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zbuf)
+	for _, name := range []string{"./", "/", "foo"} {
+		_, err := zw.Create(name)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(zw.Close(), IsNil)
+
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	c.Assert(err, IsNil)
+
+	out := new(bytes.Buffer)
+	w := siva.NewWriter(out)
+	c.Assert(siva.FromZip(w, zr), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	i, err := siva.NewReader(bytes.NewReader(out.Bytes())).Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, 1)
+	c.Assert(i[0].Name, Equals, "foo")
+}
+
+func (s *ZipSuite) TestFromZipChecksum(c *C) {
+	zbuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zbuf)
+	fw, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "foo",
+		Method:             zip.Store,
+		CRC32:              42,
+		CompressedSize64:   3,
+		UncompressedSize64: 3,
+	})
+	c.Assert(err, IsNil)
+	_, err = fw.Write([]byte("foo"))
+	c.Assert(err, IsNil)
+	c.Assert(zw.Close(), IsNil)
+
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	c.Assert(err, IsNil)
+
+	w := siva.NewWriter(new(bytes.Buffer))
+	err = siva.FromZip(w, zr)
+	c.Assert(err, NotNil)
+}
+
+func (s *ZipSuite) TestToZipChecksum(c *C) {
+	data, err := ioutil.ReadFile("fixtures/basic.siva")
+	c.Assert(err, IsNil)
+
+	// corrupt the content of the first file
+	data[0] ^= 0xff
+
+	r := siva.NewReader(bytes.NewReader(data))
+	zw := zip.NewWriter(new(bytes.Buffer))
+	err = siva.ToZip(zw, r)
+	c.Assert(err, Equals, siva.ErrInvalidCheckshum)
+}
+
+func (s *ZipSuite) TestZip64(c *C) {
+	if testing.Short() {
+		c.Skip("skipping zip64 test in short mode")
+	}
+
+	// more entries than fit in the end of central directory record force
+	// the zip64 records, files over 4GiB would too but are too slow to test
+	entries := 1<<16 + 1
+
+	buf := new(bytes.Buffer)
+	w := siva.NewWriter(buf)
+	for j := 0; j < entries; j++ {
+		writeEntry(c, w, fmt.Sprintf("%06d", j), "")
+	}
+
+	c.Assert(w.Close(), IsNil)
+
+	out := new(bytes.Buffer)
+	zw := zip.NewWriter(out)
+	c.Assert(siva.ToZip(zw, siva.NewReader(bytes.NewReader(buf.Bytes()))), IsNil)
+	c.Assert(zw.Close(), IsNil)
+
+	// the zip64 end of central directory record signature
+	c.Assert(bytes.Contains(out.Bytes(), []byte("PK\x06\x06")), Equals, true)
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	c.Assert(err, IsNil)
+	c.Assert(zr.File, HasLen, entries)
+
+	back := new(bytes.Buffer)
+	w = siva.NewWriter(back)
+	c.Assert(siva.FromZip(w, zr), IsNil)
+	c.Assert(w.Close(), IsNil)
+
+	i, err := siva.NewReader(bytes.NewReader(back.Bytes())).Index()
+	c.Assert(err, IsNil)
+	c.Assert(i, HasLen, entries)
+	c.Assert(i[entries-1].Name, Equals, fmt.Sprintf("%06d", entries-1))
+}